@@ -0,0 +1,80 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+//go:build wasip1
+
+// Sample session hook module. Build it with:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o region.wasm ./demo/wasm/region
+package main
+
+import (
+	"unsafe"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultRegion = "us-west-2"
+
+// buffers keeps the memory handed to the host reachable until the call ends.
+var buffers [][]byte
+
+//go:wasmimport session_manager reject
+func reject(pointer, length uint32)
+
+//go:wasmexport alloc
+func alloc(length uint32) uint32 {
+	buffer := make([]byte, length)
+	buffers = append(buffers, buffer)
+
+	return pointerOf(buffer)
+}
+
+//go:wasmexport on_session_created
+func onSessionCreated(pointer, length uint32) uint64 {
+	request := &sessionmanager.SessionCreatedRequest{}
+	if err := proto.Unmarshal(unsafe.Slice((*byte)(unsafe.Pointer(uintptr(pointer))), length), request); err != nil {
+		fail(err.Error())
+
+		return 0
+	}
+
+	session := request.GetSession()
+	if session.GetSession().GetConfiguration().GetMaxPlayers() > 100 {
+		fail("max_players can't be above 100")
+
+		return 0
+	}
+	attributes := utils.EnsureStruct(&session.Session.Attributes)
+	if _, err := utils.GetValue(attributes, "region"); err != nil {
+		_ = utils.SetValue(attributes, "region", defaultRegion)
+	}
+
+	output, err := proto.Marshal(session)
+	if err != nil {
+		fail(err.Error())
+
+		return 0
+	}
+	buffers = append(buffers, output)
+
+	return uint64(pointerOf(output))<<32 | uint64(len(output))
+}
+
+func fail(message string) {
+	buffer := []byte(message)
+	reject(pointerOf(buffer), uint32(len(buffer)))
+}
+
+func pointerOf(buffer []byte) uint32 {
+	if len(buffer) == 0 {
+		return 0
+	}
+
+	return uint32(uintptr(unsafe.Pointer(&buffer[0])))
+}
+
+func main() {}
@@ -0,0 +1,238 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/iamtest"
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+)
+
+const (
+	e2eNamespace = "game"
+	// e2eTimeout bounds the server start, and the wait for a revocation to be picked up.
+	e2eTimeout = 30 * time.Second
+)
+
+// TestAuthEndToEnd runs the app against a fake IAM, validating the access tokens with AGS IAM through the SDK, then
+// offline against the JWKS and revocation list files.
+func TestAuthEndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts the app")
+	}
+
+	binary := filepath.Join(t.TempDir(), "server")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build the app: %v\n%s", err, output)
+	}
+
+	// the modes run one after the other, the app listens on fixed ports
+	t.Run("SDK", func(t *testing.T) {
+		testAuth(t, binary, false)
+	})
+	t.Run("JWKS", func(t *testing.T) {
+		testAuth(t, binary, true)
+	})
+}
+
+func testAuth(t *testing.T, binary string, offline bool) {
+	fake, err := iamtest.NewServer(e2eNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	fake.AddClient("app", "secret")
+
+	env := []string{
+		"AB_BASE_URL=" + fake.URL,
+		"AB_CLIENT_ID=app",
+		"AB_CLIENT_SECRET=secret",
+		"AB_NAMESPACE=" + e2eNamespace,
+		"PLUGIN_GRPC_SERVER_AUTH_ENABLED=true",
+		"PLUGIN_GRPC_SERVER_PERMISSIONS_FILE=demo/permissions.yaml",
+		"REFRESH_INTERVAL=1",
+		"OTEL_SDK_DISABLED=true",
+	}
+	dir := t.TempDir()
+	revocationListFile := filepath.Join(dir, "revocationlist.json")
+	if offline {
+		jwksFile := filepath.Join(dir, "jwks.json")
+		jwks, err := fake.JWKS()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		writeRevocationList(t, fake, revocationListFile)
+		env = append(env,
+			"PLUGIN_GRPC_SERVER_AUTH_JWKS_FILE="+jwksFile,
+			"PLUGIN_GRPC_SERVER_AUTH_REVOCATION_LIST_FILE="+revocationListFile)
+	}
+
+	client := startApp(t, binary, env)
+
+	permissions := []iam.Permission{{Resource: "NAMESPACE:" + e2eNamespace + ":SESSION", Action: 2}}
+	allowed := mintToken(t, fake, iamtest.TokenOptions{UserID: "allowed", Permissions: permissions})
+	revokedToken := mintToken(t, fake, iamtest.TokenOptions{UserID: "revoked-token", Permissions: permissions})
+	revokedUser := mintToken(t, fake, iamtest.TokenOptions{UserID: "revoked-user", Permissions: permissions})
+
+	for _, test := range []struct {
+		name  string
+		token string
+		code  codes.Code
+	}{
+		{name: "no token", code: codes.Unauthenticated},
+		{name: "allowed", token: allowed, code: codes.OK},
+		{name: "cached", token: allowed, code: codes.OK},
+		{
+			name:  "missing permission",
+			token: mintToken(t, fake, iamtest.TokenOptions{UserID: "unprivileged"}),
+			code:  codes.PermissionDenied,
+		},
+		{
+			name: "expired",
+			token: mintToken(t, fake, iamtest.TokenOptions{
+				UserID: "expired", Permissions: permissions, ExpiresIn: -time.Hour,
+			}),
+			code: codes.PermissionDenied,
+		},
+		{name: "token to revoke", token: revokedToken, code: codes.OK},
+		{name: "user to revoke", token: revokedUser, code: codes.OK},
+	} {
+		if code := callApp(client, test.token); code != test.code {
+			t.Errorf("%s: got %v, want %v", test.name, code, test.code)
+		}
+	}
+
+	fake.RevokeToken(revokedToken)
+	fake.RevokeUser("revoked-user")
+	if offline {
+		writeRevocationList(t, fake, revocationListFile)
+	}
+
+	for name, token := range map[string]string{"revoked token": revokedToken, "revoked user": revokedUser} {
+		if code := waitForCode(client, token, codes.PermissionDenied); code != codes.PermissionDenied {
+			t.Errorf("%s: got %v, want %v", name, code, codes.PermissionDenied)
+		}
+	}
+	if code := callApp(client, allowed); code != codes.OK {
+		t.Errorf("allowed after revocations: got %v, want %v", code, codes.OK)
+	}
+}
+
+// startApp runs the app until the end of the test, and returns a client once it serves.
+func startApp(t *testing.T, binary string, env []string) sessionmanager.SessionManagerClient {
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "server.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		_ = cmd.Wait()
+		_ = logFile.Close()
+		if t.Failed() {
+			if output, err := os.ReadFile(logFile.Name()); err == nil {
+				t.Logf("app output:\n%s", output)
+			}
+		}
+	})
+
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", grpcPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	healthClient := grpc_health_v1.NewHealthClient(conn)
+	deadline := time.Now().Add(e2eTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		response, err := healthClient.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		cancel()
+		if err == nil && response.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("app not serving after %v: %v", e2eTimeout, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return sessionmanager.NewSessionManagerClient(conn)
+}
+
+// callApp calls the app with a game session of the namespace, and returns the status code of the call.
+func callApp(client sessionmanager.SessionManagerClient, token string) codes.Code {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	_, err := client.OnSessionDeleted(ctx, &sessionmanager.SessionDeletedRequest{
+		Session: &sessionmanager.GameSession{Session: &sessionmanager.BaseSession{Namespace: e2eNamespace}},
+	})
+
+	return status.Code(err)
+}
+
+// waitForCode calls the app until the call returns the code, or the timeout, and returns the last code.
+func waitForCode(client sessionmanager.SessionManagerClient, token string, want codes.Code) codes.Code {
+	deadline := time.Now().Add(e2eTimeout)
+	for {
+		code := callApp(client, token)
+		if code == want || time.Now().After(deadline) {
+			return code
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func mintToken(t *testing.T, fake *iamtest.Server, options iamtest.TokenOptions) string {
+	token, err := fake.Token(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+// writeRevocationList writes the revocation list of the fake IAM, read again by the app when its modification time
+// changes.
+func writeRevocationList(t *testing.T, fake *iamtest.Server, path string) {
+	content, err := fake.RevocationList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/admission"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/bundle"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/common"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/hook"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/placement"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/reload"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/route"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/rules"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/schema"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/script"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/teambalance"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/wasm"
+)
+
+// buildHandlers creates the configurable session hooks from the environment variables and the files they point to.
+// It is called at startup and on every configuration reload.
+func buildHandlers(ctx context.Context) (reload.Handlers, error) {
+	logger := slog.Default()
+
+	// Limits of the session scripts and WebAssembly modules, global or part of a bundle
+	handlerOptions := bundle.Options{
+		Scripts: script.Config{
+			MaxSteps: uint64(common.GetEnvInt("PLUGIN_SCRIPTS_MAX_STEPS", 1000000)),
+			Timeout:  time.Duration(common.GetEnvInt("PLUGIN_SCRIPTS_TIMEOUT_MS", 200)) * time.Millisecond,
+		},
+		Wasm: wasm.Config{
+			MemoryLimitPages: uint32(common.GetEnvInt("PLUGIN_WASM_MEMORY_LIMIT_MB", 64) * 16),
+			Timeout:          time.Duration(common.GetEnvInt("PLUGIN_WASM_TIMEOUT_MS", 500)) * time.Millisecond,
+		},
+	}
+
+	var sources []string
+	var closers []func()
+	handlers := reload.Handlers{
+		Chain: hook.NewChain(),
+		Close: func() {
+			for _, closer := range closers {
+				closer()
+			}
+		},
+	}
+	fail := func(err error) (reload.Handlers, error) {
+		handlers.Close()
+
+		return reload.Handlers{}, err
+	}
+
+	// Load the optional admission policy
+	if admissionPolicyFile := common.GetEnv("PLUGIN_ADMISSION_POLICY_FILE", ""); admissionPolicyFile != "" {
+		sources = append(sources, admissionPolicyFile)
+		admissionConfig, err := admission.LoadFile(admissionPolicyFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load admission policy: %w", err))
+		}
+		handlers.Chain.Register(admission.NewPolicy(admissionConfig))
+		logger.Info("loaded admission policy", "file", admissionPolicyFile)
+	}
+
+	// Load the optional attribute schemas, validating the client data before the other hooks change it
+	if schemasFile := common.GetEnv("PLUGIN_SCHEMAS_FILE", ""); schemasFile != "" {
+		sources = append(sources, schemasFile)
+		schemasConfig, err := schema.LoadFile(schemasFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load attribute schemas: %w", err))
+		}
+		validator, err := schema.NewValidator(schemasConfig)
+		if err != nil {
+			return fail(fmt.Errorf("invalid attribute schemas: %w", err))
+		}
+		handlers.Chain.Register(validator)
+		logger.Info("loaded attribute schemas", "schemas", validator.Schemas())
+	}
+
+	// Load the attribute injection rules
+	attributeRulesConfig := rules.DefaultConfig()
+	if attributeRulesFile := common.GetEnv("PLUGIN_ATTRIBUTE_RULES_FILE", ""); attributeRulesFile != "" {
+		sources = append(sources, attributeRulesFile)
+		loadedConfig, err := rules.LoadFile(attributeRulesFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load attribute rules: %w", err))
+		}
+		attributeRulesConfig = loadedConfig
+	}
+	attributeRules, err := rules.NewEngine(attributeRulesConfig)
+	if err != nil {
+		return fail(fmt.Errorf("invalid attribute rules: %w", err))
+	}
+	handlers.Chain.Register(attributeRules)
+	logger.Info("loaded attribute rules", "count", len(attributeRulesConfig.Rules))
+
+	// Prepare the optional team balancer
+	if strings.ToLower(common.GetEnv("PLUGIN_TEAM_BALANCE_ENABLED", "false")) == "true" {
+		teamBalanceConfig := teambalance.Config{
+			SkillAttribute: common.GetEnv("PLUGIN_TEAM_BALANCE_SKILL_ATTRIBUTE", "skills"),
+			RatingsFile:    common.GetEnv("PLUGIN_TEAM_BALANCE_RATINGS_FILE", ""),
+			DefaultSkill:   float64(common.GetEnvInt("PLUGIN_TEAM_BALANCE_DEFAULT_SKILL", 0)),
+		}
+		if teamBalanceConfig.RatingsFile != "" {
+			sources = append(sources, teamBalanceConfig.RatingsFile)
+		}
+		teamBalancer, err := teambalance.NewBalancer(teamBalanceConfig)
+		if err != nil {
+			return fail(fmt.Errorf("failed to create team balancer: %w", err))
+		}
+		handlers.Chain.Register(teamBalancer)
+		logger.Info("enabled team balancer")
+	}
+
+	// Load the optional DS placement hints, computed once the teams are final
+	if placementFile := common.GetEnv("PLUGIN_PLACEMENT_FILE", ""); placementFile != "" {
+		sources = append(sources, placementFile)
+		placementConfig, err := placement.LoadFile(placementFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load placement configuration: %w", err))
+		}
+		planner, err := placement.NewPlanner(placementConfig)
+		if err != nil {
+			return fail(fmt.Errorf("invalid placement configuration: %w", err))
+		}
+		handlers.Chain.Register(planner)
+		logger.Info("enabled DS placement hints", "file", placementFile)
+	}
+
+	// Load the optional session scripts
+	if scriptsDir := common.GetEnv("PLUGIN_SCRIPTS_DIR", ""); scriptsDir != "" {
+		sources = append(sources, scriptsDir)
+		scriptsConfig := handlerOptions.Scripts
+		scriptsConfig.Dir = scriptsDir
+		scripts, err := script.LoadDir(scriptsConfig)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load session scripts: %w", err))
+		}
+		for _, s := range scripts {
+			handlers.Chain.Register(s)
+			logger.Info("loaded session script", "name", s.Name(), "functions", s.Functions())
+		}
+	}
+
+	// Load the optional WebAssembly hook modules
+	if wasmDir := common.GetEnv("PLUGIN_WASM_DIR", ""); wasmDir != "" {
+		sources = append(sources, wasmDir)
+		wasmConfig := handlerOptions.Wasm
+		wasmConfig.Dir = wasmDir
+		wasmModules, err := wasm.LoadDir(ctx, wasmConfig)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load wasm modules: %w", err))
+		}
+		for _, m := range wasmModules {
+			closers = append(closers, func() { _ = m.Close(context.Background()) })
+			handlers.Chain.Register(m)
+			logger.Info("loaded wasm module", "name", m.Name(), "functions", m.Functions())
+		}
+	}
+
+	// Load the optional per-namespace handler bundles
+	if bundlesFile := common.GetEnv("PLUGIN_BUNDLES_FILE", ""); bundlesFile != "" {
+		sources = append(sources, bundlesFile)
+		bundlesConfig, err := bundle.LoadFile(bundlesFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load bundles: %w", err))
+		}
+		bundles, err := bundle.NewSet(ctx, bundlesConfig, handlerOptions)
+		if err != nil {
+			return fail(fmt.Errorf("failed to build bundles: %w", err))
+		}
+		sources = append(sources, bundles.Sources()...)
+		closers = append(closers, func() { _ = bundles.Close(context.Background()) })
+		handlers.Chain.Register(bundles.Router())
+		logger.Info("loaded namespace bundles", "namespaces", bundles.Namespaces())
+	}
+
+	// Load the optional configuration name routes
+	if routesFile := common.GetEnv("PLUGIN_ROUTES_FILE", ""); routesFile != "" {
+		sources = append(sources, routesFile)
+		routesConfig, err := route.LoadFile(routesFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load routes: %w", err))
+		}
+		routesBuilder := bundle.NewBuilder(handlerOptions)
+		closers = append(closers, func() { _ = routesBuilder.Close(context.Background()) })
+		routes, err := route.NewTable(ctx, routesConfig, routesBuilder)
+		if err != nil {
+			return fail(fmt.Errorf("failed to build routes: %w", err))
+		}
+		sources = append(sources, routesBuilder.Sources()...)
+		handlers.Chain.Register(routes)
+		logger.Info("loaded configuration name routes", "routes", routes.Routes())
+	}
+
+	handlers.Sources = sources
+
+	return handlers, nil
+}
@@ -19,7 +19,14 @@ import (
 	"time"
 
 	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/common"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/hook"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/journal"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/reload"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/route"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/schema"
 	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/server"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/state"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/webhook"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/contrib/propagators/b3"
 	"go.opentelemetry.io/otel"
@@ -37,6 +44,7 @@ import (
 	prometheusCollectors "github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
@@ -72,6 +80,10 @@ func parseSlogLevel(levelStr string) slog.Level {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
 	go func() {
 		runtime.SetBlockProfileRate(1)
 		runtime.SetMutexProfileFraction(10)
@@ -128,12 +140,62 @@ func main() {
 		ConfigRepository:       configRepo,
 	}
 
-	if strings.ToLower(common.GetEnv("PLUGIN_GRPC_SERVER_AUTH_ENABLED", "true")) == "true" {
-		refreshInterval := common.GetEnvInt("REFRESH_INTERVAL", 600)
-		common.Validator = common.NewTokenValidator(oauthService, time.Duration(refreshInterval)*time.Second, true)
-		err := common.Validator.Initialize(ctx)
+	// Terminate TLS, and authorize callers by client certificate, when configured
+	var serverOptions []grpc.ServerOption
+	if certFile := common.GetEnv("PLUGIN_GRPC_SERVER_TLS_CERT_FILE", ""); certFile != "" {
+		tlsConfig, err := common.NewServerTLSConfig(common.TLSConfig{
+			CertFile:     certFile,
+			KeyFile:      common.GetEnv("PLUGIN_GRPC_SERVER_TLS_KEY_FILE", ""),
+			ClientCAFile: common.GetEnv("PLUGIN_GRPC_SERVER_TLS_CLIENT_CA_FILE", ""),
+		})
 		if err != nil {
-			logger.Info(err.Error())
+			logger.Error("failed to configure TLS", "error", err)
+			os.Exit(1)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		logger.Info("enabled TLS", "cert", certFile)
+
+		if tlsConfig.ClientCAs != nil {
+			common.AllowedClientNames, err = common.ClientNames(common.GetEnv("PLUGIN_GRPC_SERVER_TLS_ALLOWED_CLIENTS", ""))
+			if err != nil {
+				logger.Error("invalid allowed clients", "error", err)
+				os.Exit(1)
+			}
+			unaryServerInterceptors = append(unaryServerInterceptors, common.UnaryClientCertServerIntercept)
+			streamServerInterceptors = append(streamServerInterceptors, common.StreamClientCertServerIntercept)
+			logger.Info("added client certificate interceptors", "allowedClients", common.AllowedClientNames)
+		}
+	}
+
+	if strings.ToLower(common.GetEnv("PLUGIN_GRPC_SERVER_AUTH_ENABLED", "true")) == "true" {
+		// validated tokens are cached, the validators refresh their keys and revocation lists in the background
+		refreshInterval := time.Duration(common.GetEnvInt("REFRESH_INTERVAL", 600)) * time.Second
+		var err error
+		if jwksFile := common.GetEnv("PLUGIN_GRPC_SERVER_AUTH_JWKS_FILE", ""); jwksFile != "" {
+			// validate tokens offline, without calling AGS IAM
+			common.Validator = common.NewCachingTokenValidator(common.NewJWKSTokenValidator(jwksFile,
+				common.GetEnv("PLUGIN_GRPC_SERVER_AUTH_REVOCATION_LIST_FILE", ""), refreshInterval), 0)
+			if err = common.Validator.Initialize(ctx); err != nil {
+				logger.Error("failed to initialize offline token validator", "error", err)
+				os.Exit(1)
+			}
+			logger.Info("validating tokens offline", "jwks", jwksFile)
+		} else {
+			// the revocation list refreshes are not observable, cached tokens are validated again after each one
+			common.Validator = common.NewCachingTokenValidator(common.NewTokenValidator(oauthService, refreshInterval, true), refreshInterval)
+			err = common.Validator.Initialize(ctx)
+			if err != nil {
+				logger.Info(err.Error())
+			}
+		}
+
+		if permissionsFile := common.GetEnv("PLUGIN_GRPC_SERVER_PERMISSIONS_FILE", ""); permissionsFile != "" {
+			common.MethodPermissions, err = common.LoadMethodPermissions(permissionsFile)
+			if err != nil {
+				logger.Error("failed to load method permissions", "file", permissionsFile, "error", err)
+				os.Exit(1)
+			}
+			logger.Info("loaded method permissions", "methods", len(common.MethodPermissions))
 		}
 
 		unaryServerInterceptors = append(unaryServerInterceptors, common.UnaryAuthServerIntercept)
@@ -141,13 +203,83 @@ func main() {
 		logger.Info("added auth interceptors")
 	}
 
-	gRPCServer := grpc.NewServer(
+	// Open the optional event journal
+	if journalDir := common.GetEnv("PLUGIN_JOURNAL_DIR", ""); journalDir != "" {
+		eventJournal, err := journal.Open(journal.Config{
+			Dir:         journalDir,
+			SegmentSize: int64(common.GetEnvInt("PLUGIN_JOURNAL_SEGMENT_SIZE_MB", 64)) * 1024 * 1024,
+			MaxSegments: common.GetEnvInt("PLUGIN_JOURNAL_MAX_SEGMENTS", 0),
+		})
+		if err != nil {
+			logger.Error("failed to open event journal", "dir", journalDir, "error", err)
+			os.Exit(1)
+		}
+		defer eventJournal.Close()
+
+		unaryServerInterceptors = append(unaryServerInterceptors, eventJournal.UnaryServerInterceptor)
+		logger.Info("added event journal interceptor", "dir", journalDir)
+	}
+
+	// Start the optional webhook forwarder
+	var webhooks *webhook.Forwarder
+	if webhookConfigFile := common.GetEnv("PLUGIN_WEBHOOK_CONFIG_FILE", ""); webhookConfigFile != "" {
+		webhookConfig, err := webhook.LoadFile(webhookConfigFile)
+		if err != nil {
+			logger.Error("failed to load webhook configuration", "file", webhookConfigFile, "error", err)
+			os.Exit(1)
+		}
+		webhooks, err = webhook.NewForwarder(webhookConfig)
+		if err != nil {
+			logger.Error("invalid webhook configuration", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			closeCtx, closeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer closeCancel()
+			if err := webhooks.Close(closeCtx); err != nil {
+				logger.Error("failed to flush webhook queue", "error", err)
+			}
+		}()
+		logger.Info("started webhook forwarder", "endpoints", len(webhookConfig.Endpoints))
+	}
+
+	// Build the configurable session hooks, rebuilt on SIGHUP or when their configuration files change
+	reloader, err := reload.New(ctx, buildHandlers)
+	if err != nil {
+		logger.Error("failed to build session hooks", "error", err)
+		os.Exit(1)
+	}
+	defer reloader.Close()
+	go func() {
+		watchFiles := strings.ToLower(common.GetEnv("PLUGIN_CONFIG_WATCH_ENABLED", "true")) == "true"
+		if err := reloader.Watch(ctx, watchFiles); err != nil {
+			logger.Error("failed to watch hook configuration", "error", err)
+		}
+	}()
+
+	// Keep the live sessions and parties, readable by the hooks through state.FromContext
+	liveState := state.NewStore()
+	unaryServerInterceptors = append(unaryServerInterceptors, liveState.UnaryServerInterceptor)
+
+	serverOptions = append(serverOptions,
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(unaryServerInterceptors...),
 		grpc.ChainStreamInterceptor(streamServerInterceptors...),
 	)
+	gRPCServer := grpc.NewServer(serverOptions...)
+	// Chain the session hooks. Disabled modules are nil and skipped.
+	hooks := hook.NewChain(
+		reloader,
+		liveState,
+		server.NewSampleActionDispatcher(),
+		// last, for the events to only be published once the other hooks handled them
+		webhooks,
+	)
+	logger.Info("registered session hooks", "hooks", hooks.Names())
+
 	service := &server.SessionManager{
 		UnimplementedSessionManagerServer: registered_v1.UnimplementedSessionManagerServer{},
+		Hooks:                             hooks,
 	}
 	registered_v1.RegisterSessionManagerServer(gRPCServer, service)
 
@@ -165,7 +297,12 @@ func main() {
 		prometheusCollectors.NewGoCollector(),
 		prometheusCollectors.NewProcessCollector(prometheusCollectors.ProcessCollectorOpts{}),
 		srvMetrics,
+		liveState,
+		reloader,
 	)
+	prometheusRegistry.MustRegister(route.Collectors()...)
+	prometheusRegistry.MustRegister(schema.Collectors()...)
+	prometheusRegistry.MustRegister(common.AuthCollectors()...)
 
 	go func() {
 		http.Handle(metricsEndpoint, promhttp.HandlerFor(prometheusRegistry, promhttp.HandlerOpts{}))
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+)
+
+// SessionHandler handles an action of a game session update.
+type SessionHandler func(ctx context.Context, sessionOld, sessionNew *sessionmanager.GameSession) error
+
+// PartyHandler handles an action of a party session update.
+type PartyHandler func(ctx context.Context, sessionOld, sessionNew *sessionmanager.PartySession) error
+
+// Result describes what a dispatch did.
+type Result struct {
+	// Actions are the known actions decoded from the flags, in ascending order.
+	Actions []sessionmanager.Action
+	// Unknown holds the bits of the flags not matching any known action.
+	Unknown uint32
+}
+
+// Dispatcher decodes the Action bitmask of update requests and invokes the handlers registered for each action.
+type Dispatcher struct {
+	sessionHandlers map[sessionmanager.Action][]SessionHandler
+	partyHandlers   map[sessionmanager.Action][]PartyHandler
+}
+
+var knownActions = func() []sessionmanager.Action {
+	known := make([]sessionmanager.Action, 0, len(sessionmanager.Action_name))
+	for value := range sessionmanager.Action_name {
+		if value != int32(sessionmanager.Action_None) {
+			known = append(known, sessionmanager.Action(value))
+		}
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i] < known[j] })
+
+	return known
+}()
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		sessionHandlers: map[sessionmanager.Action][]SessionHandler{},
+		partyHandlers:   map[sessionmanager.Action][]PartyHandler{},
+	}
+}
+
+// Decode splits the flags into the known actions and the remaining unknown bits.
+func Decode(flags sessionmanager.Action) ([]sessionmanager.Action, uint32) {
+	remaining := uint32(flags)
+	var decoded []sessionmanager.Action
+	for _, action := range knownActions {
+		if remaining&uint32(action) != 0 {
+			decoded = append(decoded, action)
+			remaining &^= uint32(action)
+		}
+	}
+
+	return decoded, remaining
+}
+
+// HandleSession registers a handler for a game session action. Handlers of the same action run in registration order.
+func (d *Dispatcher) HandleSession(action sessionmanager.Action, handler SessionHandler) {
+	d.sessionHandlers[action] = append(d.sessionHandlers[action], handler)
+}
+
+// HandleParty registers a handler for a party session action. Handlers of the same action run in registration order.
+func (d *Dispatcher) HandleParty(action sessionmanager.Action, handler PartyHandler) {
+	d.partyHandlers[action] = append(d.partyHandlers[action], handler)
+}
+
+// DispatchSession invokes the handlers of every action set in the request. All handlers are invoked even if some fail.
+func (d *Dispatcher) DispatchSession(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) (Result, error) {
+	decoded, unknown := Decode(request.GetAction())
+
+	var errs []error
+	for _, action := range decoded {
+		for _, handler := range d.sessionHandlers[action] {
+			if err := handler(ctx, request.GetSessionOld(), request.GetSessionNew()); err != nil {
+				errs = append(errs, fmt.Errorf("%s handler: %w", action, err))
+			}
+		}
+	}
+
+	return Result{Actions: decoded, Unknown: unknown}, errors.Join(errs...)
+}
+
+// DispatchParty invokes the handlers of every action set in the request. All handlers are invoked even if some fail.
+func (d *Dispatcher) DispatchParty(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) (Result, error) {
+	decoded, unknown := Decode(request.GetAction())
+
+	var errs []error
+	for _, action := range decoded {
+		for _, handler := range d.partyHandlers[action] {
+			if err := handler(ctx, request.GetSessionOld(), request.GetSessionNew()); err != nil {
+				errs = append(errs, fmt.Errorf("%s handler: %w", action, err))
+			}
+		}
+	}
+
+	return Result{Actions: decoded, Unknown: unknown}, errors.Join(errs...)
+}
+
+func (d *Dispatcher) Name() string {
+	return "actions"
+}
+
+// OnSessionUpdated dispatches the update. Handler failures are logged, they do not fail the update.
+func (d *Dispatcher) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	result, err := d.DispatchSession(ctx, request)
+	logResult(result, err)
+
+	return nil
+}
+
+// OnPartyUpdated dispatches the update. Handler failures are logged, they do not fail the update.
+func (d *Dispatcher) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	result, err := d.DispatchParty(ctx, request)
+	logResult(result, err)
+
+	return nil
+}
+
+func logResult(result Result, err error) {
+	logger := slog.Default()
+	logger.Info("dispatched actions", "actions", result.Actions)
+	if result.Unknown != 0 {
+		logger.Warn("unknown action flags", "flags", fmt.Sprintf("%#x", result.Unknown))
+	}
+	if err != nil {
+		logger.Error("action handlers failed", "error", err)
+	}
+}
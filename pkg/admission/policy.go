@@ -0,0 +1,152 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package admission
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+const violationType = "SESSION_CONFIGURATION"
+
+// Config is the content of an admission policy file.
+type Config struct {
+	// Default applies to namespaces without rules of their own.
+	Default Rules `yaml:"default" json:"default"`
+	// Namespaces holds rules replacing the default ones for specific namespaces.
+	Namespaces map[string]Rules `yaml:"namespaces" json:"namespaces"`
+}
+
+// Rules are the house rules a session configuration must follow to be admitted.
+type Rules struct {
+	// MaxPlayersCap is the highest allowed max_players, 0 means no cap.
+	MaxPlayersCap int32 `yaml:"maxPlayersCap" json:"maxPlayersCap"`
+	// AllowedJoinabilities lists the allowed joinability values, empty means any.
+	AllowedJoinabilities []string `yaml:"allowedJoinabilities" json:"allowedJoinabilities"`
+	// ForbiddenTypes lists the session types that can't be created.
+	ForbiddenTypes []string `yaml:"forbiddenTypes" json:"forbiddenTypes"`
+}
+
+// Policy decides whether sessions and parties are allowed to be created.
+type Policy struct {
+	config Config
+}
+
+// LoadFile reads an admission policy file.
+func LoadFile(path string) (Config, error) {
+	var config Config
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("failed to parse admission policy file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+func NewPolicy(config Config) *Policy {
+	return &Policy{config: config}
+}
+
+// CheckSession returns a FailedPrecondition status error when the game session must not be created.
+func (p *Policy) CheckSession(session *sessionmanager.GameSession) error {
+	return p.check(session.GetSession())
+}
+
+// CheckParty returns a FailedPrecondition status error when the party must not be created.
+func (p *Policy) CheckParty(session *sessionmanager.PartySession) error {
+	return p.check(session.GetSession())
+}
+
+func (p *Policy) check(session *sessionmanager.BaseSession) error {
+	rules, found := p.config.Namespaces[session.GetNamespace()]
+	if !found {
+		rules = p.config.Default
+	}
+
+	violations := rules.violations(session.GetConfiguration())
+	if len(violations) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(violations))
+	for i, violation := range violations {
+		descriptions[i] = violation.Description
+	}
+
+	st := status.New(codes.FailedPrecondition, fmt.Sprintf("session configuration %q rejected: %s",
+		session.GetConfigurationName(), strings.Join(descriptions, "; ")))
+	if detailed, err := st.WithDetails(&errdetails.PreconditionFailure{Violations: violations}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+func (r Rules) violations(configuration *sessionmanager.Configuration) []*errdetails.PreconditionFailure_Violation {
+	var violations []*errdetails.PreconditionFailure_Violation
+	violate := func(subject string, format string, args ...interface{}) {
+		violations = append(violations, &errdetails.PreconditionFailure_Violation{
+			Type:        violationType,
+			Subject:     subject,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	maxPlayers := configuration.GetMaxPlayers()
+	minPlayers := configuration.GetMinPlayers()
+	if r.MaxPlayersCap > 0 && maxPlayers > r.MaxPlayersCap {
+		violate("max_players", "max_players %d is above the cap of %d", maxPlayers, r.MaxPlayersCap)
+	}
+	// a max_players of 0 is unset, and leaves min_players unbounded
+	if maxPlayers > 0 && minPlayers > maxPlayers {
+		violate("min_players", "min_players %d is greater than max_players %d", minPlayers, maxPlayers)
+	}
+
+	joinability := configuration.GetJoinability()
+	if len(r.AllowedJoinabilities) > 0 && !slices.Contains(r.AllowedJoinabilities, joinability) {
+		violate("joinability", "joinability %q is not allowed", joinability)
+	}
+
+	sessionType := configuration.GetType()
+	if slices.Contains(r.ForbiddenTypes, sessionType) {
+		violate("type", "type %q is forbidden", sessionType)
+	}
+
+	return violations
+}
+
+func (p *Policy) Name() string {
+	return "admission"
+}
+
+func (p *Policy) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	if err := p.CheckSession(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (p *Policy) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	if err := p.CheckParty(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
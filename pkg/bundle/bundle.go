@@ -0,0 +1,248 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/admission"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/hook"
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/placement"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/rules"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/schema"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/script"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/teambalance"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/wasm"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of a bundles file.
+type Config struct {
+	// Default handles the namespaces without a bundle of their own.
+	Default Bundle `yaml:"default" json:"default"`
+	// Namespaces holds the bundle of each namespace.
+	Namespaces map[string]Bundle `yaml:"namespaces" json:"namespaces"`
+}
+
+// Bundle is the handler configuration of a namespace. Each handler is disabled when its field is empty.
+type Bundle struct {
+	AttributeRulesFile  string              `yaml:"attributeRulesFile" json:"attributeRulesFile"`
+	AdmissionPolicyFile string              `yaml:"admissionPolicyFile" json:"admissionPolicyFile"`
+	SchemasFile         string              `yaml:"schemasFile" json:"schemasFile"`
+	TeamBalance         *teambalance.Config `yaml:"teamBalance" json:"teamBalance"`
+	PlacementFile       string              `yaml:"placementFile" json:"placementFile"`
+	ScriptsDir          string              `yaml:"scriptsDir" json:"scriptsDir"`
+	WasmDir             string              `yaml:"wasmDir" json:"wasmDir"`
+}
+
+// Options holds the limits of the scripts and WebAssembly modules of every bundle. Their Dir is ignored.
+type Options struct {
+	Scripts script.Config
+	Wasm    wasm.Config
+}
+
+// Set holds the hook chain built for each bundle.
+type Set struct {
+	fallback   *hook.Chain
+	namespaces map[string]*hook.Chain
+	builder    *Builder
+}
+
+// Builder builds the hook chains of bundles, keeping the WebAssembly modules it loads to close them.
+type Builder struct {
+	options     Options
+	wasmModules []*wasm.Module
+	sources     []string
+}
+
+func LoadFile(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse bundles file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// NewSet builds the handlers of every bundle, reporting the first bundle failing to build.
+func NewSet(ctx context.Context, config Config, options Options) (*Set, error) {
+	set := &Set{namespaces: map[string]*hook.Chain{}, builder: NewBuilder(options)}
+
+	var err error
+	if set.fallback, err = set.builder.Build(ctx, config.Default); err != nil {
+		_ = set.Close(ctx)
+
+		return nil, fmt.Errorf("default bundle: %w", err)
+	}
+	for namespace, bundle := range config.Namespaces {
+		if set.namespaces[namespace], err = set.builder.Build(ctx, bundle); err != nil {
+			_ = set.Close(ctx)
+
+			return nil, fmt.Errorf("bundle of namespace %s: %w", namespace, err)
+		}
+	}
+
+	return set, nil
+}
+
+// Chain returns the chain of the namespace bundle, or of the default bundle.
+func (s *Set) Chain(namespace string) *hook.Chain {
+	if chain, found := s.namespaces[namespace]; found {
+		return chain
+	}
+
+	return s.fallback
+}
+
+// Namespaces returns the namespaces having a bundle, sorted.
+func (s *Set) Namespaces() []string {
+	namespaces := make([]string, 0, len(s.namespaces))
+	for namespace := range s.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	return namespaces
+}
+
+// Router returns a hook routing every event to the bundle of the session namespace.
+func (s *Set) Router() *hook.Router {
+	return hook.NewRouter("namespace-bundles", func(session *sessionmanager.BaseSession) *hook.Chain {
+		return s.Chain(session.GetNamespace())
+	})
+}
+
+// Sources returns the files and directories read by the bundles.
+func (s *Set) Sources() []string {
+	return s.builder.Sources()
+}
+
+// Close releases the WebAssembly modules of the bundles.
+func (s *Set) Close(ctx context.Context) error {
+	return s.builder.Close(ctx)
+}
+
+func NewBuilder(options Options) *Builder {
+	return &Builder{options: options}
+}
+
+// Build creates the hooks of a bundle, in the same order as the global ones.
+func (b *Builder) Build(ctx context.Context, bundle Bundle) (*hook.Chain, error) {
+	chain := hook.NewChain()
+
+	if bundle.AdmissionPolicyFile != "" {
+		b.sources = append(b.sources, bundle.AdmissionPolicyFile)
+		admissionConfig, err := admission.LoadFile(bundle.AdmissionPolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		chain.Register(admission.NewPolicy(admissionConfig))
+	}
+
+	if bundle.SchemasFile != "" {
+		b.sources = append(b.sources, bundle.SchemasFile)
+		schemasConfig, err := schema.LoadFile(bundle.SchemasFile)
+		if err != nil {
+			return nil, err
+		}
+		validator, err := schema.NewValidator(schemasConfig)
+		if err != nil {
+			return nil, err
+		}
+		chain.Register(validator)
+	}
+
+	if bundle.AttributeRulesFile != "" {
+		b.sources = append(b.sources, bundle.AttributeRulesFile)
+		rulesConfig, err := rules.LoadFile(bundle.AttributeRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		engine, err := rules.NewEngine(rulesConfig)
+		if err != nil {
+			return nil, err
+		}
+		chain.Register(engine)
+	}
+
+	if bundle.TeamBalance != nil {
+		if bundle.TeamBalance.RatingsFile != "" {
+			b.sources = append(b.sources, bundle.TeamBalance.RatingsFile)
+		}
+		balancer, err := teambalance.NewBalancer(*bundle.TeamBalance)
+		if err != nil {
+			return nil, err
+		}
+		chain.Register(balancer)
+	}
+
+	if bundle.PlacementFile != "" {
+		b.sources = append(b.sources, bundle.PlacementFile)
+		placementConfig, err := placement.LoadFile(bundle.PlacementFile)
+		if err != nil {
+			return nil, err
+		}
+		planner, err := placement.NewPlanner(placementConfig)
+		if err != nil {
+			return nil, err
+		}
+		chain.Register(planner)
+	}
+
+	if bundle.ScriptsDir != "" {
+		b.sources = append(b.sources, bundle.ScriptsDir)
+		scriptsConfig := b.options.Scripts
+		scriptsConfig.Dir = bundle.ScriptsDir
+		scripts, err := script.LoadDir(scriptsConfig)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range scripts {
+			chain.Register(s)
+		}
+	}
+
+	if bundle.WasmDir != "" {
+		b.sources = append(b.sources, bundle.WasmDir)
+		wasmConfig := b.options.Wasm
+		wasmConfig.Dir = bundle.WasmDir
+		modules, err := wasm.LoadDir(ctx, wasmConfig)
+		if err != nil {
+			return nil, err
+		}
+		b.wasmModules = append(b.wasmModules, modules...)
+		for _, m := range modules {
+			chain.Register(m)
+		}
+	}
+
+	return chain, nil
+}
+
+// Sources returns the files and directories read by the built chains.
+func (b *Builder) Sources() []string {
+	return b.sources
+}
+
+// Close releases the WebAssembly modules of the built chains.
+func (b *Builder) Close(ctx context.Context) error {
+	var err error
+	for _, module := range b.wasmModules {
+		if closeErr := module.Close(ctx); closeErr != nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
@@ -8,6 +8,8 @@ import (
 	"context"
 	"crypto/rsa"
 	"encoding/base64"
+	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
@@ -16,6 +18,8 @@ import (
 	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
 	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/utils/auth/validator"
 
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -26,7 +30,7 @@ var Validator validator.AuthTokenValidator
 
 func UnaryAuthServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	if !skipCheckAuthorizationMetadata(info.FullMethod) {
-		err := checkAuthorizationMetadata(ctx)
+		err := checkAuthorizationMetadata(ctx, info.FullMethod, requestNamespace(req))
 
 		if err != nil {
 			return nil, err
@@ -38,7 +42,8 @@ func UnaryAuthServerIntercept(ctx context.Context, req interface{}, info *grpc.U
 
 func StreamAuthServerIntercept(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	if !skipCheckAuthorizationMetadata(info.FullMethod) {
-		err := checkAuthorizationMetadata(ss.Context())
+		// a stream has no request to read the namespace from
+		err := checkAuthorizationMetadata(ss.Context(), info.FullMethod, "")
 
 		if err != nil {
 			return err
@@ -60,7 +65,7 @@ func skipCheckAuthorizationMetadata(fullMethod string) bool {
 	return false
 }
 
-func checkAuthorizationMetadata(ctx context.Context) error {
+func checkAuthorizationMetadata(ctx context.Context, fullMethod string, namespace string) error {
 	if Validator == nil {
 		return status.Error(codes.Internal, "authorization token validator is not set")
 	}
@@ -68,31 +73,106 @@ func checkAuthorizationMetadata(ctx context.Context) error {
 	meta, found := metadata.FromIncomingContext(ctx)
 
 	if !found {
-		return status.Error(codes.Unauthenticated, "metadata is missing")
+		return deny(fullMethod, "", status.Error(codes.Unauthenticated, "metadata is missing"))
 	}
 
 	if _, ok := meta["authorization"]; !ok {
-		return status.Error(codes.Unauthenticated, "authorization metadata is missing")
+		return deny(fullMethod, "", status.Error(codes.Unauthenticated, "authorization metadata is missing"))
 	}
 
 	if len(meta["authorization"]) == 0 {
-		return status.Error(codes.Unauthenticated, "authorization metadata length is 0")
+		return deny(fullMethod, "", status.Error(codes.Unauthenticated, "authorization metadata length is 0"))
 	}
 
 	authorization := meta["authorization"][0]
 	token := strings.TrimPrefix(authorization, "Bearer ")
-	namespace := os.Getenv("AB_NAMESPACE")
-
-	Validator.Initialize(ctx)
-	err := Validator.Validate(token, nil, &namespace, nil)
 
+	// the token must be valid, and hold the permission of the method, for the namespace of the request
+	namespace, err := servedNamespace(namespace)
 	if err != nil {
-		return status.Error(codes.PermissionDenied, err.Error())
+		return deny(fullMethod, clientID(token), status.Error(codes.PermissionDenied, err.Error()))
+	}
+	if err = Validator.Validate(token, MethodPermissions[fullMethod], &namespace, nil); err != nil {
+		return deny(fullMethod, clientID(token), status.Error(codes.PermissionDenied, err.Error()))
 	}
 
 	return nil
 }
 
+// servedNamespace returns the namespace to validate the token of a request against: the namespace of its session
+// when the app serves it, or the only namespace served by the app for the requests without one. The empty namespace
+// is used when AB_NAMESPACE is not set, as before.
+func servedNamespace(namespace string) (string, error) {
+	namespaces := Namespaces()
+	switch {
+	case len(namespaces) == 0:
+		return "", nil
+	case namespace == "" && len(namespaces) == 1:
+		return namespaces[0], nil
+	case namespace == "":
+		return "", fmt.Errorf("request has no session namespace, expected one of %v", namespaces)
+	}
+	for _, served := range namespaces {
+		if served == namespace {
+			return namespace, nil
+		}
+	}
+
+	return "", fmt.Errorf("namespace %s is not served by the app", namespace)
+}
+
+// requestNamespace returns the namespace of the session of a SessionManager request, empty for the other requests.
+func requestNamespace(req interface{}) string {
+	var session *sessionmanager.BaseSession
+	switch request := req.(type) {
+	case interface {
+		GetSession() *sessionmanager.GameSession
+	}:
+		session = request.GetSession().GetSession()
+	case interface {
+		GetSession() *sessionmanager.PartySession
+	}:
+		session = request.GetSession().GetSession()
+	case interface {
+		GetSessionNew() *sessionmanager.GameSession
+		GetSessionOld() *sessionmanager.GameSession
+	}:
+		session = request.GetSessionNew().GetSession()
+		if session == nil {
+			session = request.GetSessionOld().GetSession()
+		}
+	case interface {
+		GetSessionNew() *sessionmanager.PartySession
+		GetSessionOld() *sessionmanager.PartySession
+	}:
+		session = request.GetSessionNew().GetSession()
+		if session == nil {
+			session = request.GetSessionOld().GetSession()
+		}
+	}
+
+	return session.GetNamespace()
+}
+
+// deny logs a denied call and returns its error.
+func deny(fullMethod string, clientID string, err error) error {
+	slog.Default().Warn("denied gRPC call", "method", fullMethod, "clientId", clientID, "error", err)
+
+	return err
+}
+
+// Namespaces returns the namespaces of the comma separated AB_NAMESPACE list.
+func Namespaces() []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(os.Getenv("AB_NAMESPACE"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	return namespaces
+}
+
 func NewTokenValidator(authService iam.OAuth20Service, refreshInterval time.Duration, validateLocally bool) validator.AuthTokenValidator {
 	return &validator.TokenValidator{
 		AuthService:     authService,
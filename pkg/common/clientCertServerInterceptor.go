@@ -0,0 +1,156 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AllowedClientNames are the path.Match patterns of the client certificate names allowed to call the app, matched
+// against the subject common name and the DNS, URI and email SANs. Any client certificate signed by the client CA
+// is allowed when it is empty.
+var AllowedClientNames []string
+
+// TLSConfig holds the files of the gRPC server TLS termination.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the CA certificates client certificates must be signed by. Client certificates are not
+	// requested when it is empty.
+	ClientCAFile string
+}
+
+// NewServerTLSConfig returns the TLS configuration of the gRPC server. Client certificates are verified when they
+// are given, the client certificate interceptors reject the calls without one.
+func NewServerTLSConfig(config TLSConfig) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if config.ClientCAFile != "" {
+		content, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("no certificate found in client CA file %s", config.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = clientCAs
+		// health checks and reflection can be called without a client certificate
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+func UnaryClientCertServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !skipCheckAuthorizationMetadata(info.FullMethod) {
+		err := checkClientCertificate(ctx, info.FullMethod)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+func StreamClientCertServerIntercept(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !skipCheckAuthorizationMetadata(info.FullMethod) {
+		err := checkClientCertificate(ss.Context(), info.FullMethod)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler(srv, ss)
+}
+
+func checkClientCertificate(ctx context.Context, fullMethod string) error {
+	p, found := peer.FromContext(ctx)
+	if !found {
+		return deny(fullMethod, "", status.Error(codes.Unauthenticated, "peer is missing"))
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return deny(fullMethod, "", status.Error(codes.Unauthenticated, "connection is not using TLS"))
+	}
+	if len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return deny(fullMethod, "", status.Error(codes.Unauthenticated, "client certificate is missing"))
+	}
+
+	certificate := tlsInfo.State.VerifiedChains[0][0]
+	if err := authorizeClientCertificate(certificate); err != nil {
+		return deny(fullMethod, certificate.Subject.String(), status.Error(codes.PermissionDenied, err.Error()))
+	}
+
+	return nil
+}
+
+func authorizeClientCertificate(certificate *x509.Certificate) error {
+	if len(AllowedClientNames) == 0 {
+		return nil
+	}
+
+	names := clientCertificateNames(certificate)
+	for _, pattern := range AllowedClientNames {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return nil
+			}
+		}
+	}
+
+	return errors.New("client certificate " + strings.Join(names, ", ") + " is not allowed")
+}
+
+func clientCertificateNames(certificate *x509.Certificate) []string {
+	var names []string
+	if certificate.Subject.CommonName != "" {
+		names = append(names, certificate.Subject.CommonName)
+	}
+	names = append(names, certificate.DNSNames...)
+	for _, uri := range certificate.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, certificate.EmailAddresses...)
+
+	return names
+}
+
+// ClientNames returns the comma separated patterns of a client name allowlist.
+func ClientNames(allowlist string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(allowlist, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if _, err := path.Match(name, ""); err != nil {
+				return nil, fmt.Errorf("invalid client name pattern %q: %w", name, err)
+			}
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
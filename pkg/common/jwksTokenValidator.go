@@ -0,0 +1,312 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/accelbyte-go-sdk/iam-sdk/pkg/iamclientmodels"
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/utils/auth/validator"
+	"github.com/AccelByte/bloom"
+	jose "github.com/AccelByte/go-jose"
+	"github.com/AccelByte/go-jose/jwt"
+)
+
+// JWKSTokenValidator validates access tokens without calling AGS IAM, against the public keys of a JWKS file and the
+// revoked tokens and users of an optional revocation list file. The files have the content of the IAM
+// /iam/v3/oauth/jwks and /iam/v3/oauth/revocationlist endpoints, and are read again every RefreshInterval in the
+// background when they change.
+//
+// Only the permissions claims of a token are checked, the permissions of its roles need IAM.
+type JWKSTokenValidator struct {
+	JWKSFile           string
+	RevocationListFile string
+	RefreshInterval    time.Duration
+
+	refresh sync.Once
+	loading sync.Mutex
+	version atomic.Uint64
+
+	mu             sync.RWMutex
+	keys           jose.JSONWebKeySet
+	revokedTokens  *bloom.Filter
+	revokedUsers   map[string]time.Time
+	jwksModTime    time.Time
+	revocationTime time.Time
+}
+
+var _ validator.AuthTokenValidator = (*JWKSTokenValidator)(nil)
+
+func NewJWKSTokenValidator(jwksFile string, revocationListFile string, refreshInterval time.Duration) validator.AuthTokenValidator {
+	return &JWKSTokenValidator{
+		JWKSFile:           jwksFile,
+		RevocationListFile: revocationListFile,
+		RefreshInterval:    refreshInterval,
+		revokedUsers:       make(map[string]time.Time),
+	}
+}
+
+// Initialize reads the JWKS and revocation list files, and starts reading them again in the background when they
+// change, until the context is done. The previous keys and revocations are kept when a file can't be read.
+func (v *JWKSTokenValidator) Initialize(ctx ...context.Context) error {
+	err := v.load()
+	if err != nil {
+		return err
+	}
+
+	v.refresh.Do(func() {
+		refreshContext := context.Background()
+		if len(ctx) > 0 && ctx[0] != nil {
+			refreshContext = ctx[0]
+		}
+		if v.RefreshInterval > 0 {
+			go v.refreshFiles(refreshContext)
+		}
+	})
+
+	return nil
+}
+
+// Version changes every time the JWKS or the revocation list file is read again.
+func (v *JWKSTokenValidator) Version() uint64 {
+	return v.version.Load()
+}
+
+func (v *JWKSTokenValidator) refreshFiles(ctx context.Context) {
+	ticker := time.NewTicker(v.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.load(); err != nil {
+				slog.Default().Warn("failed to refresh offline token validator", "error", err)
+			}
+		}
+	}
+}
+
+func (v *JWKSTokenValidator) load() error {
+	v.loading.Lock()
+	defer v.loading.Unlock()
+
+	if err := v.loadJWKS(); err != nil {
+		return fmt.Errorf("failed to load JWKS file %s: %w", v.JWKSFile, err)
+	}
+	if v.RevocationListFile != "" {
+		if err := v.loadRevocationList(); err != nil {
+			return fmt.Errorf("failed to load revocation list file %s: %w", v.RevocationListFile, err)
+		}
+	}
+
+	return nil
+}
+
+func (v *JWKSTokenValidator) loadJWKS() error {
+	content, modTime, changed, err := readIfChanged(v.JWKSFile, v.jwksModTime)
+	if err != nil || !changed {
+		return err
+	}
+
+	var keys jose.JSONWebKeySet
+	if err = json.Unmarshal(content, &keys); err != nil {
+		return err
+	}
+	if len(keys.Keys) == 0 {
+		return errors.New("no key found")
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.jwksModTime = modTime
+	v.mu.Unlock()
+	v.version.Add(1)
+
+	return nil
+}
+
+func (v *JWKSTokenValidator) loadRevocationList() error {
+	content, modTime, changed, err := readIfChanged(v.RevocationListFile, v.revocationTime)
+	if err != nil || !changed {
+		return err
+	}
+
+	var revocationList iamclientmodels.OauthapiRevocationList
+	if err = json.Unmarshal(content, &revocationList); err != nil {
+		return err
+	}
+
+	var revokedTokens *bloom.Filter
+	if revocationList.RevokedTokens != nil && revocationList.RevokedTokens.K != nil {
+		revokedTokens = bloom.From(revocationList.RevokedTokens.Bits, uint(*revocationList.RevokedTokens.K))
+	}
+	revokedUsers := make(map[string]time.Time)
+	for _, revokedUser := range revocationList.RevokedUsers {
+		if revokedUser != nil && revokedUser.ID != nil {
+			revokedUsers[*revokedUser.ID] = time.Time(revokedUser.RevokedAt)
+		}
+	}
+
+	v.mu.Lock()
+	v.revokedTokens = revokedTokens
+	v.revokedUsers = revokedUsers
+	v.revocationTime = modTime
+	v.mu.Unlock()
+	v.version.Add(1)
+
+	return nil
+}
+
+// readIfChanged reads a file when its modification time is not the given one.
+func readIfChanged(path string, modTime time.Time) ([]byte, time.Time, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, modTime, false, err
+	}
+	if info.ModTime().Equal(modTime) {
+		return nil, modTime, false, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, modTime, false, err
+	}
+
+	return content, info.ModTime(), true, nil
+}
+
+// Validate checks the signature, expiry and revocation of a token, that it can be used in the namespace, and that
+// its permissions claims hold the permission, after replacing the {namespace} and {userId} resource placeholders.
+func (v *JWKSTokenValidator) Validate(token string, permission *iam.Permission, namespace *string, userId *string) error {
+	jsonWebToken, err := jwt.ParseSigned(token)
+	if err != nil {
+		return err
+	}
+	if len(jsonWebToken.Headers) == 0 {
+		return errors.New("no headers found")
+	}
+	kid := jsonWebToken.Headers[0].KeyID
+	if kid == "" {
+		return errors.New("'kid' header not found")
+	}
+
+	v.mu.RLock()
+	keys := v.keys.Key(kid)
+	v.mu.RUnlock()
+	if len(keys) == 0 {
+		return errors.New("public key not found")
+	}
+
+	var claims iam.JWTClaims
+	if err = jsonWebToken.Claims(keys[0].Key, &claims); err != nil {
+		return err
+	}
+	if claims.Expiry == 0 {
+		return errors.New("token has no expiry")
+	}
+	if err = claims.Validate(); err != nil {
+		return err
+	}
+
+	if err = v.checkRevocation(token, claims); err != nil {
+		return err
+	}
+
+	if namespace == nil {
+		return errors.New("trying to validate access token against a namespace, but have an empty namespace")
+	}
+	if claims.ExtendNamespace != "" && claims.ExtendNamespace != *namespace {
+		return errors.New("extend namespace from token has a different namespace than the gRPC server")
+	}
+
+	if permission == nil || permission.Resource == "" {
+		return nil
+	}
+	if claims.Namespace == "" {
+		return errors.New("claims namespace is empty")
+	}
+	resource := strings.ReplaceAll(permission.Resource, "{namespace}", *namespace)
+	if userId != nil {
+		resource = strings.ReplaceAll(resource, "{userId}", *userId)
+	}
+	for _, granted := range claims.Permissions {
+		if granted.Action&permission.Action > 0 && resourceMatches(granted.Resource, resource) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("insufficient permissions in local validation. failed to validate permission [%v][%v]", resource, permission.Action)
+}
+
+func (v *JWKSTokenValidator) checkRevocation(token string, claims iam.JWTClaims) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.revokedTokens != nil && v.revokedTokens.MightContain([]byte(token)) {
+		return errors.New("token was revoked")
+	}
+	if revokedAt, found := v.revokedUsers[claims.Subject]; found &&
+		revokedAt.Unix() >= int64(claims.IssuedAt) {
+		return errors.New("user was revoked")
+	}
+
+	return nil
+}
+
+// resourceMatches reports whether a granted permission resource covers a required one, the way IAM matches them: a
+// "*" item matches any item, a trailing "*" matches the remaining items unless it stands for a namespace or user ID,
+// and a "NAMESPACE:<studio>-" item matches the studio namespace and its "<studio>-<game>" namespaces. IAM looks up
+// the studio of the namespaces with more "-" in their namespace context, which needs IAM, so they never match a
+// studio item here.
+func resourceMatches(granted string, required string) bool {
+	grantedItems := strings.Split(granted, ":")
+	requiredItems := strings.Split(required, ":")
+
+	for i := 0; i < min(len(grantedItems), len(requiredItems)); i++ {
+		item := grantedItems[i]
+		if item == "*" || item == requiredItems[i] {
+			continue
+		}
+		if i > 0 && grantedItems[i-1] == "NAMESPACE" && strings.HasSuffix(item, "-") &&
+			((strings.Count(requiredItems[i], "-") == 1 && strings.HasPrefix(requiredItems[i], item)) ||
+				requiredItems[i]+"-" == item) {
+			continue
+		}
+
+		return false
+	}
+
+	switch {
+	case len(grantedItems) < len(requiredItems):
+		if grantedItems[len(grantedItems)-1] != "*" {
+			return false
+		}
+		if len(grantedItems) >= 2 {
+			previous := grantedItems[len(grantedItems)-2]
+
+			return previous != "NAMESPACE" && previous != "USER"
+		}
+	case len(grantedItems) > len(requiredItems):
+		for _, item := range grantedItems[len(requiredItems):] {
+			if item != "*" {
+				return false
+			}
+		}
+	}
+
+	return true
+}
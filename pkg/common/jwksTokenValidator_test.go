@@ -0,0 +1,40 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import "testing"
+
+func TestResourceMatches(t *testing.T) {
+	for _, test := range []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{granted: "NAMESPACE:game:SESSION", required: "NAMESPACE:game:SESSION", want: true},
+		{granted: "NAMESPACE:game:SESSION", required: "NAMESPACE:other:SESSION", want: false},
+		{granted: "NAMESPACE:*:SESSION", required: "NAMESPACE:game:SESSION", want: true},
+		{granted: "NAMESPACE:game:*", required: "NAMESPACE:game:SESSION", want: true},
+		{granted: "NAMESPACE:game:SESSION:*", required: "NAMESPACE:game:SESSION", want: true},
+		{granted: "NAMESPACE:game:SESSION:USER", required: "NAMESPACE:game:SESSION", want: false},
+		{granted: "NAMESPACE:game:SESSION", required: "NAMESPACE:game:SESSION:USER", want: false},
+		{granted: "NAMESPACE:game:*", required: "NAMESPACE:game:SESSION:USER", want: true},
+		// a trailing "*" standing for a namespace or user ID doesn't match the remaining items
+		{granted: "NAMESPACE:*", required: "NAMESPACE:game:SESSION", want: false},
+		{granted: "NAMESPACE:game:USER:*", required: "NAMESPACE:game:USER:user-a:SESSION", want: false},
+		{granted: "NAMESPACE:game:USER:*", required: "NAMESPACE:game:USER:user-a", want: true},
+		// studio items
+		{granted: "NAMESPACE:abc-:SESSION", required: "NAMESPACE:abc-def:SESSION", want: true},
+		{granted: "NAMESPACE:abc-:SESSION", required: "NAMESPACE:abc:SESSION", want: true},
+		{granted: "NAMESPACE:abc-:SESSION", required: "NAMESPACE:xyz-def:SESSION", want: false},
+		{granted: "NAMESPACE:abc-:SESSION", required: "NAMESPACE:abcd:SESSION", want: false},
+		{granted: "NAMESPACE:abc-:SESSION", required: "NAMESPACE:abc-def-ghi:SESSION", want: false},
+		{granted: "NAMESPACE:abc-def-:SESSION", required: "NAMESPACE:abc-def:SESSION", want: true},
+		{granted: "SESSION:abc-", required: "SESSION:abc-def", want: false},
+	} {
+		if got := resourceMatches(test.granted, test.required); got != test.want {
+			t.Errorf("resourceMatches(%q, %q) = %v, want %v", test.granted, test.required, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	"gopkg.in/yaml.v3"
+)
+
+// Actions of the IAM permissions, combined with a bitwise or.
+const (
+	ActionCreate = 1
+	ActionRead   = 2
+	ActionUpdate = 4
+	ActionDelete = 8
+)
+
+var actionNames = map[string]int{
+	"CREATE": ActionCreate,
+	"READ":   ActionRead,
+	"UPDATE": ActionUpdate,
+	"DELETE": ActionDelete,
+}
+
+// MethodPermissions maps gRPC full method names to the IAM permission required to call them. Methods missing from
+// the map only require a valid token. The {namespace} placeholder of a resource is replaced by the validated namespace.
+var MethodPermissions = map[string]*iam.Permission{}
+
+// PermissionsConfig is the content of a method permissions file.
+type PermissionsConfig struct {
+	Permissions map[string]MethodPermission `yaml:"permissions" json:"permissions"`
+}
+
+// MethodPermission is the permission required to call a method, e.g. NAMESPACE:{namespace}:SESSION and READ.
+type MethodPermission struct {
+	Resource string `yaml:"resource" json:"resource"`
+	// Action is an action name, a "|" separated list of names such as "CREATE|UPDATE", or the action number.
+	Action string `yaml:"action" json:"action"`
+}
+
+// LoadMethodPermissions reads a method permissions file into the permissions checked by the auth interceptors.
+func LoadMethodPermissions(path string) (map[string]*iam.Permission, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config PermissionsConfig
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse method permissions file %s: %w", path, err)
+	}
+
+	permissions := map[string]*iam.Permission{}
+	for method, permission := range config.Permissions {
+		if !strings.HasPrefix(method, "/") {
+			return nil, fmt.Errorf("method %s: not a full method name, e.g. /package.Service/Method", method)
+		}
+		if permission.Resource == "" {
+			return nil, fmt.Errorf("method %s: missing resource", method)
+		}
+		action, err := parseAction(permission.Action)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", method, err)
+		}
+		permissions[method] = &iam.Permission{Resource: permission.Resource, Action: action}
+	}
+
+	return permissions, nil
+}
+
+func parseAction(action string) (int, error) {
+	if number, err := strconv.Atoi(action); err == nil && number > 0 {
+		return number, nil
+	}
+
+	var parsed int
+	for _, name := range strings.Split(action, "|") {
+		value, found := actionNames[strings.ToUpper(strings.TrimSpace(name))]
+		if !found {
+			return 0, fmt.Errorf("unknown action %q", name)
+		}
+		parsed |= value
+	}
+
+	return parsed, nil
+}
+
+// tokenClaims are the claims of a token read without validating it.
+type tokenClaims struct {
+	ClientID string `json:"client_id"`
+	Expiry   int64  `json:"exp"`
+}
+
+// parseTokenClaims returns the claims of a token, without validating it. They must only be trusted after the token
+// was validated.
+func parseTokenClaims(token string) (tokenClaims, bool) {
+	var claims tokenClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, false
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+
+	return claims, true
+}
+
+// clientID returns the client_id claim of a token, without validating it. It is only meant for logging.
+func clientID(token string) string {
+	claims, _ := parseTokenClaims(token)
+
+	return claims.ClientID
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/utils/auth/validator"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// maxCachedTokens bounds the number of validations kept by a CachingTokenValidator.
+	maxCachedTokens = 10000
+	// initializeRetryInterval is the delay between the attempts to initialize a validator which failed to.
+	initializeRetryInterval = 10 * time.Second
+)
+
+var (
+	tokenCacheTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "session_manager_token_cache_requests_total",
+		Help: "Number of token validations answered from the validated token cache, or not",
+	}, []string{"result"})
+	tokenValidationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "session_manager_token_validation_duration_seconds",
+		Help:    "Duration of the token validations, including the ones answered from the validated token cache",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"cache", "result"})
+)
+
+// AuthCollectors returns the metrics of the validated token cache, shared by every caching validator.
+func AuthCollectors() []prometheus.Collector {
+	return []prometheus.Collector{tokenCacheTotal, tokenValidationDuration}
+}
+
+// Versioner is implemented by the validators able to tell when their keys or revocation list change, the version
+// changes with them.
+type Versioner interface {
+	Version() uint64
+}
+
+// CachingTokenValidator remembers the successful validations of a validator, keyed by the token hash and the checked
+// permission, namespace and user ID, until the exp claim of the token. The cache is emptied when the keys or the
+// revocation list of a Versioner validator change, and a validation is kept at most MaxAge when set, for the validators
+// refreshing them without telling.
+type CachingTokenValidator struct {
+	Validator validator.AuthTokenValidator
+	MaxAge    time.Duration
+
+	mu       sync.Mutex
+	expiries map[tokenCacheKey]time.Time
+	version  uint64
+}
+
+type tokenCacheKey struct {
+	token      [sha256.Size]byte
+	permission string
+	namespace  string
+	userId     string
+}
+
+var _ validator.AuthTokenValidator = (*CachingTokenValidator)(nil)
+
+func NewCachingTokenValidator(tokenValidator validator.AuthTokenValidator, maxAge time.Duration) *CachingTokenValidator {
+	return &CachingTokenValidator{
+		Validator: tokenValidator,
+		MaxAge:    maxAge,
+		expiries:  make(map[tokenCacheKey]time.Time),
+	}
+}
+
+// Initialize initializes the validator, which refreshes its keys and revocation list in the background from then on.
+// It is called once, not on every call, and a failed initialization is retried in the background until it succeeds.
+func (v *CachingTokenValidator) Initialize(ctx ...context.Context) error {
+	err := v.Validator.Initialize(ctx...)
+	if err != nil {
+		go v.retryInitialize(ctx...)
+	}
+
+	return err
+}
+
+func (v *CachingTokenValidator) retryInitialize(ctx ...context.Context) {
+	done := context.Background().Done()
+	if len(ctx) > 0 && ctx[0] != nil {
+		done = ctx[0].Done()
+	}
+
+	ticker := time.NewTicker(initializeRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := v.Validator.Initialize(ctx...)
+			if err == nil {
+				slog.Default().Info("initialized token validator")
+
+				return
+			}
+			slog.Default().Warn("failed to initialize token validator", "error", err)
+		}
+	}
+}
+
+func (v *CachingTokenValidator) Validate(token string, permission *iam.Permission, namespace *string, userId *string) error {
+	start := time.Now()
+	key := newTokenCacheKey(token, permission, namespace, userId)
+
+	found, version := v.lookup(key, start)
+	if found {
+		tokenCacheTotal.WithLabelValues("hit").Inc()
+		tokenValidationDuration.WithLabelValues("hit", "ok").Observe(time.Since(start).Seconds())
+
+		return nil
+	}
+	tokenCacheTotal.WithLabelValues("miss").Inc()
+
+	err := v.Validator.Validate(token, permission, namespace, userId)
+	result := "ok"
+	if err != nil {
+		result = "error"
+	} else {
+		v.store(key, token, start, version)
+	}
+	tokenValidationDuration.WithLabelValues("miss", result).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+func newTokenCacheKey(token string, permission *iam.Permission, namespace *string, userId *string) tokenCacheKey {
+	key := tokenCacheKey{token: sha256.Sum256([]byte(token))}
+	if permission != nil {
+		key.permission = permission.Resource + "|" + strconv.Itoa(permission.Action)
+	}
+	if namespace != nil {
+		key.namespace = *namespace
+	}
+	if userId != nil {
+		key.userId = *userId
+	}
+
+	return key
+}
+
+// lookup reports whether a validation is cached, and returns the validator version it is looked up with.
+func (v *CachingTokenValidator) lookup(key tokenCacheKey, now time.Time) (bool, uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.checkVersion()
+	expiry, found := v.expiries[key]
+	if !found {
+		return false, v.version
+	}
+	if !now.Before(expiry) {
+		delete(v.expiries, key)
+
+		return false, v.version
+	}
+
+	return true, v.version
+}
+
+// store caches a validation, unless the validator version changed since it was looked up.
+func (v *CachingTokenValidator) store(key tokenCacheKey, token string, now time.Time, version uint64) {
+	claims, ok := parseTokenClaims(token)
+	if !ok || claims.Expiry == 0 {
+		return
+	}
+	expiry := time.Unix(claims.Expiry, 0)
+	if v.MaxAge > 0 && now.Add(v.MaxAge).Before(expiry) {
+		expiry = now.Add(v.MaxAge)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.checkVersion()
+	if v.version != version {
+		return
+	}
+	if len(v.expiries) >= maxCachedTokens {
+		for cachedKey, cachedExpiry := range v.expiries {
+			if !now.Before(cachedExpiry) {
+				delete(v.expiries, cachedKey)
+			}
+		}
+		if len(v.expiries) >= maxCachedTokens {
+			v.expiries = make(map[tokenCacheKey]time.Time)
+		}
+	}
+	v.expiries[key] = expiry
+}
+
+// checkVersion empties the cache when the keys or the revocation list of the validator changed. It is called with the
+// lock held.
+func (v *CachingTokenValidator) checkVersion() {
+	versioner, ok := v.Validator.(Versioner)
+	if !ok {
+		return
+	}
+	if version := versioner.Version(); version != v.version {
+		v.expiries = make(map[tokenCacheKey]time.Time)
+		v.version = version
+	}
+}
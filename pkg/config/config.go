@@ -14,12 +14,42 @@ import (
 //nolint:lll
 type Config struct {
 	// awsgamelift Config
-	GRPCPort                    int  `env:"GRPC_PORT" envDocs:"The Port gRPC listens to" envDefault:"6565"`
-	PluginGRPCServerAuthEnabled bool `env:"PLUGIN_GRPC_SERVER_AUTH_ENABLED" envDocs:"Enable or disable access token and permission verification" envDefault:""`
+	GRPCPort                               int    `env:"GRPC_PORT" envDocs:"The Port gRPC listens to" envDefault:"6565"`
+	PluginGRPCServerAuthEnabled            bool   `env:"PLUGIN_GRPC_SERVER_AUTH_ENABLED" envDocs:"Enable or disable access token and permission verification" envDefault:""`
+	PluginGRPCServerTLSCertFile            string `env:"PLUGIN_GRPC_SERVER_TLS_CERT_FILE" envDocs:"Path of the PEM certificate of the gRPC server, TLS is disabled when empty" envDefault:""`
+	PluginGRPCServerTLSKeyFile             string `env:"PLUGIN_GRPC_SERVER_TLS_KEY_FILE" envDocs:"Path of the PEM private key of the gRPC server certificate" envDefault:""`
+	PluginGRPCServerTLSClientCAFile        string `env:"PLUGIN_GRPC_SERVER_TLS_CLIENT_CA_FILE" envDocs:"Path of the PEM CA certificates client certificates must be signed by, client certificates are not required when empty" envDefault:""`
+	PluginGRPCServerTLSAllowedClients      string `env:"PLUGIN_GRPC_SERVER_TLS_ALLOWED_CLIENTS" envDocs:"Comma separated glob patterns of the allowed client certificate common names and SANs, any client certificate signed by the CA is allowed when empty" envDefault:""`
+	RefreshInterval                        int    `env:"REFRESH_INTERVAL" envDocs:"Interval in seconds of the refreshes of the token validation keys and revocation list, and maximum age of the cached validations with AGS IAM" envDefault:"600"`
+	PluginGRPCServerAuthJWKSFile           string `env:"PLUGIN_GRPC_SERVER_AUTH_JWKS_FILE" envDocs:"Path of the IAM JWKS file tokens are validated against offline, tokens are validated with AGS IAM when empty" envDefault:""`
+	PluginGRPCServerAuthRevocationListFile string `env:"PLUGIN_GRPC_SERVER_AUTH_REVOCATION_LIST_FILE" envDocs:"Path of the IAM revocation list file of the offline token validation, no token is revoked when empty" envDefault:""`
+	PluginGRPCServerPermissionsFile        string `env:"PLUGIN_GRPC_SERVER_PERMISSIONS_FILE" envDocs:"Path of the file of the IAM permission required by each gRPC method, only a valid token is required when empty" envDefault:""`
 	// AB Config
 	ABBaseURL      string `env:"AB_BASE_URL" envDocs:"Base URL of AccelByte Gaming Services" envDefault:""`
 	ABClientId     string `env:"AB_CLIENT_ID" envDocs:"Client ID from the Prerequisites section" envDefault:""`
 	ABClientSecret string `env:"AB_CLIENT_SECRET" envDocs:"Client Secret from the Prerequisites section" envDefault:""`
+	// Plugin Config
+	PluginAttributeRulesFile        string `env:"PLUGIN_ATTRIBUTE_RULES_FILE" envDocs:"Path to a YAML or JSON attribute injection rules file, the sample rules are used when empty" envDefault:""`
+	PluginTeamBalanceEnabled        bool   `env:"PLUGIN_TEAM_BALANCE_ENABLED" envDocs:"Enable or disable skill-aware team rebalancing on game session creation" envDefault:"false"`
+	PluginTeamBalanceSkillAttribute string `env:"PLUGIN_TEAM_BALANCE_SKILL_ATTRIBUTE" envDocs:"Dotted path of the session attribute holding an object of user ID to skill" envDefault:"skills"`
+	PluginTeamBalanceRatingsFile    string `env:"PLUGIN_TEAM_BALANCE_RATINGS_FILE" envDocs:"Path to a YAML or JSON file of user ID to skill" envDefault:""`
+	PluginTeamBalanceDefaultSkill   int    `env:"PLUGIN_TEAM_BALANCE_DEFAULT_SKILL" envDocs:"Skill used for users with no known skill" envDefault:"0"`
+	PluginAdmissionPolicyFile       string `env:"PLUGIN_ADMISSION_POLICY_FILE" envDocs:"Path to a YAML or JSON admission policy file, every session is admitted when empty" envDefault:""`
+	PluginWebhookConfigFile         string `env:"PLUGIN_WEBHOOK_CONFIG_FILE" envDocs:"Path to a YAML or JSON webhook configuration file, events are not forwarded when empty" envDefault:""`
+	PluginJournalDir                string `env:"PLUGIN_JOURNAL_DIR" envDocs:"Directory of the event journal of handled callbacks, the journal is disabled when empty" envDefault:""`
+	PluginJournalSegmentSizeMB      int    `env:"PLUGIN_JOURNAL_SEGMENT_SIZE_MB" envDocs:"Size in MB after which the event journal starts a new segment file" envDefault:"64"`
+	PluginJournalMaxSegments        int    `env:"PLUGIN_JOURNAL_MAX_SEGMENTS" envDocs:"Number of event journal segment files kept, 0 keeps every segment" envDefault:"0"`
+	PluginScriptsDir                string `env:"PLUGIN_SCRIPTS_DIR" envDocs:"Directory of the Starlark session scripts, scripting is disabled when empty" envDefault:""`
+	PluginScriptsMaxSteps           int    `env:"PLUGIN_SCRIPTS_MAX_STEPS" envDocs:"Maximum Starlark computation steps of a single script call, 0 is unlimited" envDefault:"1000000"`
+	PluginScriptsTimeoutMS          int    `env:"PLUGIN_SCRIPTS_TIMEOUT_MS" envDocs:"Timeout in milliseconds of a single script call, 0 is unlimited" envDefault:"200"`
+	PluginWasmDir                   string `env:"PLUGIN_WASM_DIR" envDocs:"Directory of the WebAssembly hook modules, WebAssembly hooks are disabled when empty" envDefault:""`
+	PluginWasmMemoryLimitMB         int    `env:"PLUGIN_WASM_MEMORY_LIMIT_MB" envDocs:"Maximum memory in MB of a WebAssembly module instance" envDefault:"64"`
+	PluginWasmTimeoutMS             int    `env:"PLUGIN_WASM_TIMEOUT_MS" envDocs:"Timeout in milliseconds of a single WebAssembly module call, 0 is unlimited" envDefault:"500"`
+	PluginBundlesFile               string `env:"PLUGIN_BUNDLES_FILE" envDocs:"Path of the per-namespace handler bundles file, namespace bundles are disabled when empty" envDefault:""`
+	PluginRoutesFile                string `env:"PLUGIN_ROUTES_FILE" envDocs:"Path of the configuration name routes file, routing is disabled when empty" envDefault:""`
+	PluginSchemasFile               string `env:"PLUGIN_SCHEMAS_FILE" envDocs:"Path of the session attributes JSON Schemas file, attributes are not validated when empty" envDefault:""`
+	PluginPlacementFile             string `env:"PLUGIN_PLACEMENT_FILE" envDocs:"Path of the DS placement hints file, placement hints are disabled when empty" envDefault:""`
+	PluginConfigWatchEnabled        bool   `env:"PLUGIN_CONFIG_WATCH_ENABLED" envDocs:"Reload the session hooks when their configuration files change, they are always reloaded on SIGHUP" envDefault:"true"`
 }
 
 // HelpDocs returns documentation of Config based on field tags.
@@ -0,0 +1,21 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package diff
+
+import "context"
+
+type changeSetKey struct{}
+
+// NewContext returns a context carrying the changes of an updated session, for the hooks and action handlers.
+func NewContext(ctx context.Context, changes ChangeSet) context.Context {
+	return context.WithValue(ctx, changeSetKey{}, changes)
+}
+
+// FromContext returns the changes carried by the context.
+func FromContext(ctx context.Context) (ChangeSet, bool) {
+	changes, ok := ctx.Value(changeSetKey{}).(ChangeSet)
+
+	return changes, ok
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type Kind string
+
+const (
+	Added    Kind = "added"
+	Removed  Kind = "removed"
+	Modified Kind = "modified"
+)
+
+// Change is a change of a value inside attributes, storages or configuration.
+type Change struct {
+	// Path is the dotted path of the value, e.g. "match.settings.map".
+	Path string
+	Kind Kind
+	// Old is nil when the value was added.
+	Old *structpb.Value
+	// New is nil when the value was removed.
+	New *structpb.Value
+}
+
+// StatusChange is a change of the status of a member.
+type StatusChange struct {
+	UserID string
+	From   string
+	To     string
+}
+
+// StringChange is a change of a single string field.
+type StringChange struct {
+	From string
+	To   string
+}
+
+// ChangeSet holds the differences between two snapshots of a session.
+type ChangeSet struct {
+	MembersAdded   []string
+	MembersRemoved []string
+	StatusChanges  []StatusChange
+	Leader         *StringChange
+	Attributes     []Change
+	Storages       []Change
+	Configuration  []Change
+	// DSStatus is only set for game sessions.
+	DSStatus *StringChange
+}
+
+// Sessions compares two snapshots of a game session.
+func Sessions(sessionOld, sessionNew *sessionmanager.GameSession) ChangeSet {
+	changes := Base(sessionOld.GetSession(), sessionNew.GetSession())
+
+	statusOld, statusNew := dsStatus(sessionOld.GetDsInformation()), dsStatus(sessionNew.GetDsInformation())
+	if statusOld != statusNew {
+		changes.DSStatus = &StringChange{From: statusOld, To: statusNew}
+	}
+
+	return changes
+}
+
+// Parties compares two snapshots of a party session.
+func Parties(sessionOld, sessionNew *sessionmanager.PartySession) ChangeSet {
+	return Base(sessionOld.GetSession(), sessionNew.GetSession())
+}
+
+// Base compares two snapshots of the common part of game and party sessions.
+func Base(sessionOld, sessionNew *sessionmanager.BaseSession) ChangeSet {
+	var changes ChangeSet
+
+	membersOld := members(sessionOld.GetMembers())
+	membersNew := members(sessionNew.GetMembers())
+	for _, member := range sessionNew.GetMembers() {
+		previous, found := membersOld[member.GetId()]
+		if !found {
+			changes.MembersAdded = append(changes.MembersAdded, member.GetId())
+
+			continue
+		}
+		if from, to := userStatus(previous), userStatus(member); from != to {
+			changes.StatusChanges = append(changes.StatusChanges, StatusChange{UserID: member.GetId(), From: from, To: to})
+		}
+	}
+	for _, member := range sessionOld.GetMembers() {
+		if _, found := membersNew[member.GetId()]; !found {
+			changes.MembersRemoved = append(changes.MembersRemoved, member.GetId())
+		}
+	}
+
+	if sessionOld.GetLeaderId() != sessionNew.GetLeaderId() {
+		changes.Leader = &StringChange{From: sessionOld.GetLeaderId(), To: sessionNew.GetLeaderId()}
+	}
+
+	changes.Attributes = Structs(sessionOld.GetAttributes(), sessionNew.GetAttributes())
+	changes.Storages = Structs(sessionOld.GetStorages(), sessionNew.GetStorages())
+	changes.Configuration = configuration(sessionOld.GetConfiguration(), sessionNew.GetConfiguration())
+
+	return changes
+}
+
+// Structs compares two structs, descending into nested structs. Lists are compared as a whole.
+func Structs(structOld, structNew *structpb.Struct) []Change {
+	var changes []Change
+	compareFields("", structOld.GetFields(), structNew.GetFields(), &changes)
+
+	return changes
+}
+
+// Empty returns true when the snapshots are identical in every compared aspect.
+func (c ChangeSet) Empty() bool {
+	return len(c.MembersAdded) == 0 && len(c.MembersRemoved) == 0 && len(c.StatusChanges) == 0 &&
+		c.Leader == nil && len(c.Attributes) == 0 && len(c.Storages) == 0 && len(c.Configuration) == 0 &&
+		c.DSStatus == nil
+}
+
+// String returns a compact single line description of the change set, suitable for logs and trace attributes.
+func (c ChangeSet) String() string {
+	var parts []string
+	if len(c.MembersAdded) > 0 {
+		parts = append(parts, fmt.Sprintf("members added %v", c.MembersAdded))
+	}
+	if len(c.MembersRemoved) > 0 {
+		parts = append(parts, fmt.Sprintf("members removed %v", c.MembersRemoved))
+	}
+	for _, statusChange := range c.StatusChanges {
+		parts = append(parts, fmt.Sprintf("member %s status %s -> %s", statusChange.UserID, statusChange.From, statusChange.To))
+	}
+	if c.Leader != nil {
+		parts = append(parts, fmt.Sprintf("leader %s -> %s", c.Leader.From, c.Leader.To))
+	}
+	if c.DSStatus != nil {
+		parts = append(parts, fmt.Sprintf("DS status %s -> %s", c.DSStatus.From, c.DSStatus.To))
+	}
+	parts = appendChanges(parts, "attribute", c.Attributes)
+	parts = appendChanges(parts, "storage", c.Storages)
+	parts = appendChanges(parts, "configuration", c.Configuration)
+
+	if len(parts) == 0 {
+		return "no changes"
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func appendChanges(parts []string, name string, changes []Change) []string {
+	for _, change := range changes {
+		parts = append(parts, fmt.Sprintf("%s %s %s", name, change.Path, change.Kind))
+	}
+
+	return parts
+}
+
+func compareFields(prefix string, fieldsOld, fieldsNew map[string]*structpb.Value, changes *[]Change) {
+	keys := make([]string, 0, len(fieldsOld)+len(fieldsNew))
+	for key := range fieldsOld {
+		keys = append(keys, key)
+	}
+	for key := range fieldsNew {
+		if _, found := fieldsOld[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		valueOld, foundOld := fieldsOld[key]
+		valueNew, foundNew := fieldsNew[key]
+		switch {
+		case !foundOld:
+			*changes = append(*changes, Change{Path: path, Kind: Added, New: valueNew})
+		case !foundNew:
+			*changes = append(*changes, Change{Path: path, Kind: Removed, Old: valueOld})
+		case valueOld.GetStructValue() != nil && valueNew.GetStructValue() != nil:
+			compareFields(path, valueOld.GetStructValue().GetFields(), valueNew.GetStructValue().GetFields(), changes)
+		case !proto.Equal(valueOld, valueNew):
+			*changes = append(*changes, Change{Path: path, Kind: Modified, Old: valueOld, New: valueNew})
+		}
+	}
+}
+
+// configuration compares every configuration field, the attributes being compared like the session attributes.
+func configuration(configurationOld, configurationNew *sessionmanager.Configuration) []Change {
+	var changes []Change
+
+	messageOld := configurationOld.ProtoReflect()
+	messageNew := configurationNew.ProtoReflect()
+	fields := messageNew.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Kind() == protoreflect.MessageKind {
+			continue
+		}
+
+		valueOld := scalarValue(messageOld.Get(field))
+		valueNew := scalarValue(messageNew.Get(field))
+		if !proto.Equal(valueOld, valueNew) {
+			changes = append(changes, Change{Path: string(field.Name()), Kind: Modified, Old: valueOld, New: valueNew})
+		}
+	}
+
+	compareFields("attributes", configurationOld.GetAttributes().GetFields(), configurationNew.GetAttributes().GetFields(), &changes)
+
+	return changes
+}
+
+func scalarValue(value protoreflect.Value) *structpb.Value {
+	switch v := value.Interface().(type) {
+	case bool:
+		return structpb.NewBoolValue(v)
+	case int32:
+		return structpb.NewNumberValue(float64(v))
+	case string:
+		return structpb.NewStringValue(v)
+	default:
+		return structpb.NewStringValue(value.String())
+	}
+}
+
+func members(users []*sessionmanager.User) map[string]*sessionmanager.User {
+	byID := make(map[string]*sessionmanager.User, len(users))
+	for _, user := range users {
+		byID[user.GetId()] = user
+	}
+
+	return byID
+}
+
+func userStatus(user *sessionmanager.User) string {
+	if user.GetStatusV2() != "" {
+		return user.GetStatusV2()
+	}
+
+	return user.GetStatus()
+}
+
+func dsStatus(information *sessionmanager.DSInformation) string {
+	if information.GetStatusV2() != "" {
+		return information.GetStatusV2()
+	}
+
+	return information.GetStatus()
+}
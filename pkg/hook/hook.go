@@ -0,0 +1,188 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package hook
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"google.golang.org/grpc/status"
+)
+
+// Hook is a module reacting to session lifecycle events. A hook implements
+// one or more of the event interfaces below, and is only invoked for those.
+type Hook interface {
+	Name() string
+}
+
+// SessionCreatedHook can modify or reject a game session being created.
+type SessionCreatedHook interface {
+	Hook
+	OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error)
+}
+
+type SessionUpdatedHook interface {
+	Hook
+	OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error
+}
+
+type SessionDeletedHook interface {
+	Hook
+	OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error
+}
+
+// PartyCreatedHook can modify or reject a party session being created.
+type PartyCreatedHook interface {
+	Hook
+	OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error)
+}
+
+type PartyUpdatedHook interface {
+	Hook
+	OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error
+}
+
+type PartyDeletedHook interface {
+	Hook
+	OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error
+}
+
+// Chain invokes hooks in registration order. Each created hook receives the session returned by the previous one.
+// The chain stops at the first hook returning an error, and returns that error.
+// A nil chain has no hooks, and passes the sessions through.
+type Chain struct {
+	hooks []Hook
+}
+
+func NewChain(hooks ...Hook) *Chain {
+	chain := &Chain{}
+	for _, h := range hooks {
+		chain.Register(h)
+	}
+
+	return chain
+}
+
+// list returns the hooks of the chain, none for a nil chain.
+func (c *Chain) list() []Hook {
+	if c == nil {
+		return nil
+	}
+
+	return c.hooks
+}
+
+// Register appends a hook to the chain. Nil hooks are ignored so optional modules can be registered unconditionally.
+func (c *Chain) Register(h Hook) {
+	if isNil(h) {
+		return
+	}
+	c.hooks = append(c.hooks, h)
+}
+
+// Names returns the names of the registered hooks, in order.
+func (c *Chain) Names() []string {
+	hooks := c.list()
+	names := make([]string, len(hooks))
+	for i, h := range hooks {
+		names[i] = h.Name()
+	}
+
+	return names
+}
+
+func (c *Chain) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	for _, h := range c.list() {
+		if created, ok := h.(SessionCreatedHook); ok {
+			var err error
+			if session, err = created.OnSessionCreated(ctx, session); err != nil {
+				return nil, wrap(h, err)
+			}
+		}
+	}
+
+	return session, nil
+}
+
+func (c *Chain) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	for _, h := range c.list() {
+		if updated, ok := h.(SessionUpdatedHook); ok {
+			if err := updated.OnSessionUpdated(ctx, request); err != nil {
+				return wrap(h, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Chain) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	for _, h := range c.list() {
+		if deleted, ok := h.(SessionDeletedHook); ok {
+			if err := deleted.OnSessionDeleted(ctx, session); err != nil {
+				return wrap(h, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Chain) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	for _, h := range c.list() {
+		if created, ok := h.(PartyCreatedHook); ok {
+			var err error
+			if session, err = created.OnPartyCreated(ctx, session); err != nil {
+				return nil, wrap(h, err)
+			}
+		}
+	}
+
+	return session, nil
+}
+
+func (c *Chain) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	for _, h := range c.list() {
+		if updated, ok := h.(PartyUpdatedHook); ok {
+			if err := updated.OnPartyUpdated(ctx, request); err != nil {
+				return wrap(h, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Chain) OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error {
+	for _, h := range c.list() {
+		if deleted, ok := h.(PartyDeletedHook); ok {
+			if err := deleted.OnPartyDeleted(ctx, session); err != nil {
+				return wrap(h, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// wrap names the failing hook, leaving gRPC status errors untouched so their code reaches the caller.
+func wrap(h Hook, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return fmt.Errorf("%s hook: %w", h.Name(), err)
+}
+
+func isNil(h Hook) bool {
+	if h == nil {
+		return true
+	}
+	value := reflect.ValueOf(h)
+
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
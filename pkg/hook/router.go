@@ -0,0 +1,83 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package hook
+
+import (
+	"context"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+)
+
+// Selector returns the chain handling a session. A nil chain leaves the session unchanged.
+type Selector func(session *sessionmanager.BaseSession) *Chain
+
+// Router is a hook passing every event to the chain selected for its session.
+// Update events are routed by their new session.
+type Router struct {
+	name     string
+	selector Selector
+}
+
+func NewRouter(name string, selector Selector) *Router {
+	return &Router{name: name, selector: selector}
+}
+
+func (r *Router) Name() string {
+	return r.name
+}
+
+func (r *Router) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	chain := r.selector(session.GetSession())
+	if chain == nil {
+		return session, nil
+	}
+
+	return chain.OnSessionCreated(ctx, session)
+}
+
+func (r *Router) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	chain := r.selector(request.GetSessionNew().GetSession())
+	if chain == nil {
+		return nil
+	}
+
+	return chain.OnSessionUpdated(ctx, request)
+}
+
+func (r *Router) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	chain := r.selector(session.GetSession())
+	if chain == nil {
+		return nil
+	}
+
+	return chain.OnSessionDeleted(ctx, session)
+}
+
+func (r *Router) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	chain := r.selector(session.GetSession())
+	if chain == nil {
+		return session, nil
+	}
+
+	return chain.OnPartyCreated(ctx, session)
+}
+
+func (r *Router) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	chain := r.selector(request.GetSessionNew().GetSession())
+	if chain == nil {
+		return nil
+	}
+
+	return chain.OnPartyUpdated(ctx, request)
+}
+
+func (r *Router) OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error {
+	chain := r.selector(session.GetSession())
+	if chain == nil {
+		return nil
+	}
+
+	return chain.OnPartyDeleted(ctx, session)
+}
@@ -0,0 +1,366 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package iamtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	"github.com/AccelByte/bloom"
+	jose "github.com/AccelByte/go-jose"
+	"github.com/AccelByte/go-jose/jwt"
+)
+
+// DefaultExpiresIn is the lifetime of the tokens minted without an expiry.
+const DefaultExpiresIn = time.Hour
+
+// Server is a stand-in for AGS IAM, serving the client credentials token grant, the token verification, the JWKS, the
+// revocation list, the role permissions and the namespace contexts used by the SDK token validator, so the app can be run with
+// PLUGIN_GRPC_SERVER_AUTH_ENABLED=true and AB_BASE_URL set to the server URL without network access. It signs the
+// tokens it mints with its own RSA key.
+type Server struct {
+	*httptest.Server
+
+	// Namespace is the namespace of the client tokens.
+	Namespace string
+
+	keyID  string
+	signer jose.Signer
+	key    *rsa.PrivateKey
+
+	mu            sync.Mutex
+	clients       map[string]client
+	roles         map[string][]iam.Permission
+	revokedTokens []string
+	revokedUsers  map[string]time.Time
+}
+
+type client struct {
+	secret      string
+	permissions []iam.Permission
+}
+
+// TokenOptions are the claims of a minted token.
+type TokenOptions struct {
+	Namespace       string
+	ExtendNamespace string
+	// UserID is the subject of the token, it is a client token when empty.
+	UserID         string
+	ClientID       string
+	Permissions    []iam.Permission
+	Roles          []string
+	NamespaceRoles []iam.NamespaceRole
+	// ExpiresIn is the lifetime of the token, DefaultExpiresIn when zero. A negative lifetime mints an expired token.
+	ExpiresIn time.Duration
+}
+
+// NewServer starts a fake IAM server for the namespace. The caller must call Close when done.
+func NewServer(namespace string) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := "iamtest"
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: key, KeyID: keyID},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Namespace:    namespace,
+		keyID:        keyID,
+		signer:       signer,
+		key:          key,
+		clients:      make(map[string]client),
+		roles:        make(map[string][]iam.Permission),
+		revokedUsers: make(map[string]time.Time),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /iam/v3/oauth/token", s.handleToken)
+	mux.HandleFunc("POST /iam/v3/oauth/verify", s.handleVerify)
+	mux.HandleFunc("GET /iam/v3/oauth/jwks", s.handleJWKS)
+	mux.HandleFunc("GET /iam/v3/oauth/revocationlist", s.handleRevocationList)
+	mux.HandleFunc("GET /iam/v3/admin/namespaces/{namespace}/roleoverride/{roleId}/permissions", s.handleRolePermissions)
+	mux.HandleFunc("GET /basic/v1/admin/namespaces/{namespace}/context", s.handleNamespaceContext)
+	s.Server = httptest.NewServer(mux)
+
+	return s, nil
+}
+
+// AddClient registers an OAuth client, whose client credentials tokens hold the permissions.
+func (s *Server) AddClient(clientID string, clientSecret string, permissions ...iam.Permission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clients[clientID] = client{secret: clientSecret, permissions: permissions}
+}
+
+// SetRolePermissions sets the permissions of a role, fetched by the validator for the roles claims of a token.
+func (s *Server) SetRolePermissions(roleID string, permissions ...iam.Permission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roles[roleID] = permissions
+}
+
+// RevokeToken adds a token to the revocation list.
+func (s *Server) RevokeToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revokedTokens = append(s.revokedTokens, token)
+}
+
+// RevokeUser adds a user to the revocation list, revoking the tokens issued to the user until now.
+func (s *Server) RevokeUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revokedUsers[userID] = time.Now()
+}
+
+// Token mints a token signed by the server.
+func (s *Server) Token(options TokenOptions) (string, error) {
+	if options.Namespace == "" {
+		options.Namespace = s.Namespace
+	}
+	if options.ExpiresIn == 0 {
+		options.ExpiresIn = DefaultExpiresIn
+	}
+
+	token, _, err := s.mint(options)
+
+	return token, err
+}
+
+func (s *Server) mint(options TokenOptions) (string, iam.JWTClaims, error) {
+	issuedAt := time.Now()
+	claims := iam.JWTClaims{
+		Namespace:       options.Namespace,
+		ExtendNamespace: options.ExtendNamespace,
+		ClientID:        options.ClientID,
+		Permissions:     options.Permissions,
+		Roles:           options.Roles,
+		NamespaceRoles:  options.NamespaceRoles,
+		Claims: jwt.Claims{
+			Issuer:   s.URL,
+			Subject:  options.UserID,
+			IssuedAt: jwt.NewNumericDate(issuedAt),
+			Expiry:   jwt.NewNumericDate(issuedAt.Add(options.ExpiresIn)),
+		},
+	}
+
+	token, err := jwt.Signed(s.signer).Claims(claims).CompactSerialize()
+
+	return token, claims, err
+}
+
+// verify returns the claims of a token signed by the server, not expired nor revoked.
+func (s *Server) verify(token string) (iam.JWTClaims, error) {
+	var claims iam.JWTClaims
+	jsonWebToken, err := jwt.ParseSigned(token)
+	if err != nil {
+		return claims, err
+	}
+	if err = jsonWebToken.Claims(&s.key.PublicKey, &claims); err != nil {
+		return claims, err
+	}
+	if err = claims.Validate(); err != nil {
+		return claims, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, revokedToken := range s.revokedTokens {
+		if revokedToken == token {
+			return claims, errors.New("token was revoked")
+		}
+	}
+	if revokedAt, found := s.revokedUsers[claims.Subject]; found && revokedAt.Unix() >= int64(claims.IssuedAt) {
+		return claims, errors.New("user was revoked")
+	}
+
+	return claims, nil
+}
+
+// JWKS returns the content of the JWKS endpoint, e.g. to write the PLUGIN_GRPC_SERVER_AUTH_JWKS_FILE file.
+func (s *Server) JWKS() ([]byte, error) {
+	return json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       &s.key.PublicKey,
+		KeyID:     s.keyID,
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}})
+}
+
+// RevocationList returns the content of the revocation list endpoint, e.g. to write the
+// PLUGIN_GRPC_SERVER_AUTH_REVOCATION_LIST_FILE file.
+func (s *Server) RevocationList() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	filter := bloom.New(uint(max(len(s.revokedTokens), 1)))
+	for _, token := range s.revokedTokens {
+		filter.Put([]byte(token))
+	}
+
+	type revokedUser struct {
+		ID        string    `json:"id"`
+		RevokedAt time.Time `json:"revoked_at"`
+	}
+	revokedUsers := make([]revokedUser, 0, len(s.revokedUsers))
+	for userID, revokedAt := range s.revokedUsers {
+		revokedUsers = append(revokedUsers, revokedUser{ID: userID, RevokedAt: revokedAt.UTC()})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"revoked_tokens": map[string]interface{}{
+			"bits": filter.B(),
+			"k":    filter.K(),
+			"m":    filter.M(),
+		},
+		"revoked_users": revokedUsers,
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "only the client_credentials grant is supported")
+
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	s.mu.Lock()
+	registered, found := s.clients[clientID]
+	s.mu.Unlock()
+	if !ok || !found || registered.secret != clientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "unknown client or wrong secret")
+
+		return
+	}
+
+	token, claims, err := s.mint(TokenOptions{ClientID: clientID, Permissions: registered.permissions})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+
+		return
+	}
+
+	writeJSON(w, tokenResponse(token, claims))
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	claims, err := s.verify(token)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_token", err.Error())
+
+		return
+	}
+
+	writeJSON(w, tokenResponse(token, claims))
+}
+
+// tokenResponse returns the token response of the token grant and verification endpoints.
+func tokenResponse(token string, claims iam.JWTClaims) map[string]interface{} {
+	response := map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(claims.Expiry) - time.Now().Unix(),
+		"namespace":    claims.Namespace,
+		"permissions":  permissionsResponse(claims.Permissions),
+		"roles":        claims.Roles,
+		"scope":        "account",
+	}
+	if claims.Subject != "" {
+		response["user_id"] = claims.Subject
+	}
+
+	return response
+}
+
+func permissionsResponse(permissions []iam.Permission) []map[string]interface{} {
+	response := make([]map[string]interface{}, 0, len(permissions))
+	for _, permission := range permissions {
+		response = append(response, map[string]interface{}{"resource": permission.Resource, "action": permission.Action})
+	}
+
+	return response
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	content, err := s.JWKS()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(content)
+}
+
+func (s *Server) handleRevocationList(w http.ResponseWriter, _ *http.Request) {
+	content, err := s.RevocationList()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(content)
+}
+
+func (s *Server) handleRolePermissions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	permissions, found := s.roles[r.PathValue("roleId")]
+	s.mu.Unlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "not_found", "unknown role "+r.PathValue("roleId"))
+
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"permissions": permissionsResponse(permissions)})
+}
+
+func (s *Server) handleNamespaceContext(w http.ResponseWriter, r *http.Request) {
+	// every namespace is a game namespace, of the studio before the "-" of a "<studio>-<game>" namespace
+	namespace := r.PathValue("namespace")
+	studioNamespace, _, _ := strings.Cut(namespace, "-")
+
+	writeJSON(w, map[string]interface{}{"namespace": namespace, "type": "Game", "studioNamespace": studioNamespace})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
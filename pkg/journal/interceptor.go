@@ -0,0 +1,57 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package journal
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var sessionManagerMethodPrefix = "/" + sessionmanager.SessionManager_ServiceDesc.ServiceName + "/"
+
+// UnaryServerInterceptor records every SessionManager call, with the response returned, into the journal.
+func (j *Journal) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, sessionManagerMethodPrefix) {
+		return handler(ctx, req)
+	}
+
+	record := Record{
+		Timestamp: time.Now().UTC(),
+		Method:    info.FullMethod,
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		record.TraceID = span.TraceID().String()
+	}
+	// the request is marshaled before calling the handler since handlers modify it in place
+	if message, ok := req.(proto.Message); ok {
+		record.Request, _ = protojson.Marshal(message)
+	}
+
+	resp, err := handler(ctx, req)
+
+	record.Duration = time.Since(record.Timestamp)
+	if err != nil {
+		st := status.Convert(err)
+		record.Code = st.Code().String()
+		record.Error = st.Message()
+	} else if message, ok := resp.(proto.Message); ok {
+		record.Response, _ = protojson.Marshal(message)
+	}
+
+	if appendErr := j.Append(record); appendErr != nil {
+		slog.Default().Error("failed to append journal record", "method", info.FullMethod, "error", appendErr)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,310 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentPrefix = "journal-"
+	segmentSuffix = ".jsonl"
+
+	// maxRecordSize bounds the size of a single record read back by Iterate.
+	maxRecordSize = 64 * 1024 * 1024
+)
+
+// ErrStop can be returned by an Iterate callback to stop iterating without error.
+var ErrStop = errors.New("stop iterating")
+
+// Record is a single callback handled by the server.
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Method is the full gRPC method name, e.g. /accelbyte.session.manager.SessionManager/OnSessionCreated.
+	Method   string          `json:"method"`
+	TraceID  string          `json:"traceId,omitempty"`
+	Duration time.Duration   `json:"duration"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	// Code and Error hold the gRPC status of a failed call.
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Config specifies where and how the journal is written.
+type Config struct {
+	Dir string
+	// SegmentSize is the size in bytes after which a new segment file is started.
+	SegmentSize int64
+	// MaxSegments is the number of segment files kept, the oldest ones are deleted. 0 keeps every segment.
+	MaxSegments int
+}
+
+// Journal appends records to JSON lines segment files named journal-<sequence>.jsonl.
+type Journal struct {
+	config   Config
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	sequence int
+}
+
+// Open opens the journal of the directory, appending to its latest segment.
+func Open(config Config) (*Journal, error) {
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = 64 * 1024 * 1024
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	sequences, err := segments(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{config: config, sequence: 1}
+	if len(sequences) > 0 {
+		journal.sequence = sequences[len(sequences)-1]
+		// a crash in the middle of a write leaves a partial last line, which must not be continued by the next record
+		truncated, err := endsWithPartialLine(segmentPath(config.Dir, journal.sequence))
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			journal.sequence++
+		}
+	}
+	if err = journal.openSegment(); err != nil {
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+// Append writes a record, rotating to a new segment when the current one is full.
+func (j *Journal) Append(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return os.ErrClosed
+	}
+
+	if j.size > 0 && j.size+int64(len(line)) > j.config.SegmentSize {
+		if err = j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+
+	return err
+}
+
+// Close closes the current segment.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+
+	return err
+}
+
+// Iterate calls fn for every record present in the journal directory when it is called, oldest first.
+func Iterate(dir string, fn func(Record) error) error {
+	sequences, err := segments(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, sequence := range sequences {
+		if err = iterateSegment(segmentPath(dir, sequence), fn); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func iterateSegment(path string, fn func(Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// records appended while iterating, e.g. by a server being replayed into its own journal, are not read
+	size := info.Size()
+	truncated, err := partialLastLine(file, size)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(file, size))
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	var read int64
+	for line := 1; scanner.Scan(); line++ {
+		read += int64(len(scanner.Bytes())) + 1
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// the partial last line of a write interrupted by a crash is skipped
+			if truncated && read > size {
+				slog.Default().Warn("skipped truncated journal record", "file", path, "line", line, "error", err)
+
+				return nil
+			}
+
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// endsWithPartialLine reports whether a segment file does not end with a newline, as left by an interrupted write.
+func endsWithPartialLine(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	return partialLastLine(file, info.Size())
+}
+
+// partialLastLine reports whether the first size bytes of a segment file do not end with a newline.
+func partialLastLine(file *os.File, size int64) (bool, error) {
+	if size == 0 {
+		return false, nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err != nil {
+		return false, err
+	}
+
+	return last[0] != '\n', nil
+}
+
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	j.sequence++
+	if err := j.openSegment(); err != nil {
+		return err
+	}
+
+	if j.config.MaxSegments <= 0 {
+		return nil
+	}
+
+	sequences, err := segments(j.config.Dir)
+	if err != nil {
+		return err
+	}
+	for len(sequences) > j.config.MaxSegments {
+		if err = os.Remove(segmentPath(j.config.Dir, sequences[0])); err != nil {
+			return err
+		}
+		sequences = sequences[1:]
+	}
+
+	return nil
+}
+
+func (j *Journal) openSegment() error {
+	file, err := os.OpenFile(segmentPath(j.config.Dir, j.sequence), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	j.file = file
+	j.size = info.Size()
+
+	return nil
+}
+
+// segments returns the sequence numbers of the segment files of the directory in ascending order.
+func segments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sequences []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		sequence, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		sequences = append(sequences, sequence)
+	}
+	sort.Ints(sequences)
+
+	return sequences, nil
+}
+
+func segmentPath(dir string, sequence int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%08d%s", segmentPrefix, sequence, segmentSuffix))
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package placement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/state"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/utils"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/utils/envelope"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of a placement file.
+type Config struct {
+	// Regions are the candidate regions. When empty, every region found in the member data is a candidate.
+	Regions []string `yaml:"regions" json:"regions"`
+
+	// LatencyAttribute is the dotted path of the session attribute holding an object of user ID to an object of
+	// region to latency in milliseconds, e.g. {"latencies": {"user-a": {"us-east-1": 40}}}.
+	LatencyAttribute string `yaml:"latencyAttribute" json:"latencyAttribute"`
+	// MissingLatencyMS is the latency assumed for a region a member has no latency for.
+	MissingLatencyMS float64 `yaml:"missingLatencyMS" json:"missingLatencyMS"`
+	// PartyRegionAttribute is the dotted path of the party attribute holding the region, or the ordered list of
+	// regions, preferred by the party.
+	PartyRegionAttribute string `yaml:"partyRegionAttribute" json:"partyRegionAttribute"`
+	// PlatformRegions holds the regions preferred by the members of each platform_id.
+	PlatformRegions map[string][]string `yaml:"platformRegions" json:"platformRegions"`
+	// PlatformDeployments holds the DS deployment of each platform_id. The deployment of the platform of most
+	// members is the hint, or DefaultDeployment when the platform has none.
+	PlatformDeployments map[string]string `yaml:"platformDeployments" json:"platformDeployments"`
+	DefaultDeployment   string            `yaml:"defaultDeployment" json:"defaultDeployment"`
+
+	Weights Weights `yaml:"weights" json:"weights"`
+
+	// RegionsAttribute and DeploymentAttribute are the dotted paths of the session attributes the hints are
+	// written to.
+	RegionsAttribute    string `yaml:"regionsAttribute" json:"regionsAttribute"`
+	DeploymentAttribute string `yaml:"deploymentAttribute" json:"deploymentAttribute"`
+}
+
+// Weights of the inputs of the region score, lower scores are preferred. The preferences are worth their weight in
+// milliseconds of latency for the whole session, shared by the members having the preference.
+type Weights struct {
+	// Latency multiplies the average latency of the members to the region.
+	Latency float64 `yaml:"latency" json:"latency"`
+	// Party is subtracted for a region preferred by the parties of every member, decreasing with the preference rank.
+	Party float64 `yaml:"party" json:"party"`
+	// Platform is subtracted for a region preferred by the platforms of every member.
+	Platform float64 `yaml:"platform" json:"platform"`
+}
+
+// DefaultConfig returns the configuration completed by NewPlanner for the unset fields.
+func DefaultConfig() Config {
+	return Config{
+		LatencyAttribute:     "latencies",
+		MissingLatencyMS:     500,
+		PartyRegionAttribute: "preferredRegion",
+		Weights:              Weights{Latency: 1, Party: 50, Platform: 25},
+		RegionsAttribute:     "placement.regions",
+		DeploymentAttribute:  "placement.deployment",
+	}
+}
+
+// Planner is a hook writing the preferred regions and the DS deployment of created game sessions into their
+// attributes, for the dedicated server claim.
+type Planner struct {
+	config Config
+}
+
+// Hint is the placement computed for a game session.
+type Hint struct {
+	// Regions are the candidate regions, preferred first.
+	Regions []string
+	// Scores are the scores of the regions, in the same order.
+	Scores     []float64
+	Deployment string
+}
+
+// inputs are the member data a hint is computed from.
+type inputs struct {
+	members   []string
+	latencies map[string]map[string]float64
+	platforms map[string]string
+	// parties holds the ordered regions preferred by the party of each member
+	parties map[string][]string
+}
+
+func LoadFile(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config := DefaultConfig()
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse placement file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+func NewPlanner(config Config) (*Planner, error) {
+	defaults := DefaultConfig()
+	if config.LatencyAttribute == "" {
+		config.LatencyAttribute = defaults.LatencyAttribute
+	}
+	if config.PartyRegionAttribute == "" {
+		config.PartyRegionAttribute = defaults.PartyRegionAttribute
+	}
+	if config.RegionsAttribute == "" {
+		config.RegionsAttribute = defaults.RegionsAttribute
+	}
+	if config.DeploymentAttribute == "" {
+		config.DeploymentAttribute = defaults.DeploymentAttribute
+	}
+	if config.MissingLatencyMS < 0 || config.Weights.Latency < 0 || config.Weights.Party < 0 || config.Weights.Platform < 0 {
+		return nil, errors.New("placement weights and missing latency can't be negative")
+	}
+
+	return &Planner{config: config}, nil
+}
+
+// Plan computes the placement hint of a game session. The parties of the members are read from the live state of
+// the context, when there is one.
+func (p *Planner) Plan(ctx context.Context, session *sessionmanager.GameSession) Hint {
+	scope := envelope.ChildScopeFromRemoteScope(ctx, "placement.Plan", trace.SpanContextFromContext(ctx).TraceID().String())
+	defer scope.Finish()
+
+	in := p.inputs(ctx, session)
+	scope.SetAttributes("placement.members", len(in.members))
+	scope.SetAttributes("placement.members_with_latency", len(in.latencies))
+	scope.SetAttributes("placement.platforms", sortedValues(in.platforms))
+	scope.SetAttributes("placement.party_regions", firstPartyRegions(in.parties))
+
+	hint := Hint{Regions: p.candidates(in), Deployment: p.deployment(in)}
+	scores := map[string]float64{}
+	for _, region := range hint.Regions {
+		scores[region] = p.score(region, in)
+	}
+	sort.SliceStable(hint.Regions, func(i, j int) bool {
+		return scores[hint.Regions[i]] < scores[hint.Regions[j]]
+	})
+	for _, region := range hint.Regions {
+		hint.Scores = append(hint.Scores, scores[region])
+	}
+
+	scope.SetAttributes("placement.regions", hint.Regions)
+	scope.SetAttributes("placement.scores", hint.Scores)
+	scope.SetAttributes("placement.deployment", hint.Deployment)
+	scope.Log.Debug("computed placement hint", "session", session.GetSession().GetId(), "regions", hint.Regions,
+		"scores", hint.Scores, "deployment", hint.Deployment)
+
+	return hint
+}
+
+func (p *Planner) inputs(ctx context.Context, session *sessionmanager.GameSession) inputs {
+	in := inputs{
+		latencies: map[string]map[string]float64{},
+		platforms: map[string]string{},
+		parties:   map[string][]string{},
+	}
+
+	for _, member := range session.GetSession().GetMembers() {
+		in.members = append(in.members, member.GetId())
+		if member.GetPlatformId() != "" {
+			in.platforms[member.GetId()] = member.GetPlatformId()
+		}
+	}
+
+	latencies, err := utils.GetStruct(session.GetSession().GetAttributes(), p.config.LatencyAttribute)
+	if err == nil {
+		for userID, regions := range latencies.GetFields() {
+			in.latencies[userID] = map[string]float64{}
+			for region, latency := range regions.GetStructValue().GetFields() {
+				in.latencies[userID][region] = latency.GetNumberValue()
+			}
+		}
+	}
+
+	if store, ok := state.FromContext(ctx); ok {
+		usersOfParty := map[string][]string{}
+		for _, team := range session.GetTeams() {
+			for _, partyMember := range team.GetPartyMembers() {
+				usersOfParty[partyMember.GetPartyId()] = append(usersOfParty[partyMember.GetPartyId()], partyMember.GetUserIds()...)
+			}
+		}
+		for _, party := range store.PartiesOf(session) {
+			regions := p.partyRegions(party)
+			if len(regions) == 0 {
+				continue
+			}
+			for _, userID := range usersOfParty[party.GetSession().GetId()] {
+				in.parties[userID] = regions
+			}
+		}
+	}
+
+	return in
+}
+
+// partyRegions reads the regions preferred by a party, a single region or a list.
+func (p *Planner) partyRegions(party *sessionmanager.PartySession) []string {
+	value, err := utils.GetValue(party.GetSession().GetAttributes(), p.config.PartyRegionAttribute)
+	if err != nil {
+		return nil
+	}
+	if region := value.GetStringValue(); region != "" {
+		return []string{region}
+	}
+
+	var regions []string
+	for _, item := range value.GetListValue().GetValues() {
+		if region := item.GetStringValue(); region != "" {
+			regions = append(regions, region)
+		}
+	}
+
+	return regions
+}
+
+func (p *Planner) candidates(in inputs) []string {
+	if len(p.config.Regions) > 0 {
+		return append([]string(nil), p.config.Regions...)
+	}
+
+	seen := map[string]bool{}
+	for _, regions := range in.latencies {
+		for region := range regions {
+			seen[region] = true
+		}
+	}
+	for _, regions := range in.parties {
+		for _, region := range regions {
+			seen[region] = true
+		}
+	}
+	for _, platform := range in.platforms {
+		for _, region := range p.config.PlatformRegions[platform] {
+			seen[region] = true
+		}
+	}
+
+	candidates := make([]string, 0, len(seen))
+	for region := range seen {
+		candidates = append(candidates, region)
+	}
+	sort.Strings(candidates)
+
+	return candidates
+}
+
+func (p *Planner) score(region string, in inputs) float64 {
+	if len(in.members) == 0 {
+		return 0
+	}
+	members := float64(len(in.members))
+
+	var latency, party, platform float64
+	for _, userID := range in.members {
+		memberLatency, found := in.latencies[userID][region]
+		if !found {
+			memberLatency = p.config.MissingLatencyMS
+		}
+		latency += memberLatency
+
+		// the first preferred region is worth the full weight, the next ones less
+		for rank, preferred := range in.parties[userID] {
+			if preferred == region {
+				party += 1 / float64(rank+1)
+
+				break
+			}
+		}
+
+		for _, preferred := range p.config.PlatformRegions[in.platforms[userID]] {
+			if preferred == region {
+				platform++
+
+				break
+			}
+		}
+	}
+
+	return p.config.Weights.Latency*latency/members -
+		p.config.Weights.Party*party/members -
+		p.config.Weights.Platform*platform/members
+}
+
+// deployment returns the deployment of the platform of most members, ties going to the first platform by name.
+func (p *Planner) deployment(in inputs) string {
+	counts := map[string]int{}
+	for _, platform := range in.platforms {
+		counts[platform]++
+	}
+
+	var majority string
+	for _, platform := range sortedValues(in.platforms) {
+		if counts[platform] > counts[majority] {
+			majority = platform
+		}
+	}
+	if deployment, found := p.config.PlatformDeployments[majority]; found {
+		return deployment
+	}
+
+	return p.config.DefaultDeployment
+}
+
+func (p *Planner) Name() string {
+	return "placement"
+}
+
+func (p *Planner) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	if session.GetSession() == nil {
+		return session, nil
+	}
+
+	hint := p.Plan(ctx, session)
+	attributes := utils.EnsureStruct(&session.Session.Attributes)
+	if len(hint.Regions) > 0 {
+		regions := make([]interface{}, len(hint.Regions))
+		for i, region := range hint.Regions {
+			regions[i] = region
+		}
+		if err := utils.SetValue(attributes, p.config.RegionsAttribute, regions); err != nil {
+			slog.Default().Warn("failed to write placement regions", "session", session.GetSession().GetId(), "error", err)
+		}
+	}
+	if hint.Deployment != "" {
+		if err := utils.SetValue(attributes, p.config.DeploymentAttribute, hint.Deployment); err != nil {
+			slog.Default().Warn("failed to write placement deployment", "session", session.GetSession().GetId(), "error", err)
+		}
+	}
+
+	return session, nil
+}
+
+// sortedValues returns the distinct values of a map, sorted.
+func sortedValues(m map[string]string) []string {
+	seen := map[string]bool{}
+	var values []string
+	for _, value := range m {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+
+	return values
+}
+
+// firstPartyRegions returns the distinct first regions preferred by the parties, sorted.
+func firstPartyRegions(parties map[string][]string) []string {
+	first := map[string]string{}
+	for userID, regions := range parties {
+		first[userID] = regions[0]
+	}
+
+	return sortedValues(first)
+}
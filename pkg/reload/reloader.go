@@ -0,0 +1,325 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/hook"
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// debounce groups the file events of a single change, e.g. an editor writing a temporary file and renaming it.
+	debounce = 500 * time.Millisecond
+
+	// retireDelay is how long a replaced generation is kept before being closed, for the calls still using it.
+	retireDelay = time.Minute
+)
+
+var (
+	configInfoDesc = prometheus.NewDesc("session_manager_config_info",
+		"Version of the active hook configuration", []string{"version"}, nil)
+	configLoadedDesc = prometheus.NewDesc("session_manager_config_loaded_timestamp_seconds",
+		"Time the active hook configuration was loaded", nil, nil)
+	configReloadsDesc = prometheus.NewDesc("session_manager_config_reloads_total",
+		"Number of hook configuration reloads", []string{"result"}, nil)
+)
+
+// Handlers is a generation of hooks built from the configuration.
+type Handlers struct {
+	Chain *hook.Chain
+	// Sources are the configuration files and directories read to build the chain.
+	Sources []string
+	// Close releases the hooks, it can be nil.
+	Close func()
+}
+
+// Build creates the hooks from the current content of the configuration. It must fully validate the configuration
+// and return an error when it is invalid.
+type Build func(ctx context.Context) (Handlers, error)
+
+// Reloader is a hook passing every event to the latest successfully built generation of hooks.
+// It rebuilds them on SIGHUP or when one of their sources changes, keeping the previous generation on failure.
+type Reloader struct {
+	build Build
+	log   *slog.Logger
+
+	// mu serializes the reloads
+	mu      sync.Mutex
+	current atomic.Pointer[generation]
+
+	succeeded atomic.Int64
+	failed    atomic.Int64
+}
+
+type generation struct {
+	handlers Handlers
+	version  string
+	loadedAt time.Time
+}
+
+// New builds the first generation of hooks, failing when it can't be built.
+func New(ctx context.Context, build Build) (*Reloader, error) {
+	r := &Reloader{
+		build: build,
+		log:   slog.Default(),
+	}
+
+	g, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.current.Store(g)
+	r.log.Info("loaded hook configuration", "version", g.version, "hooks", g.handlers.Chain.Names())
+
+	return r, nil
+}
+
+// Version returns the version of the active configuration, a digest of the content of its sources.
+func (r *Reloader) Version() string {
+	return r.current.Load().version
+}
+
+// Reload builds a new generation of hooks and swaps it in. The active generation is kept when the build fails.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g, err := r.load(ctx)
+	if err != nil {
+		r.failed.Add(1)
+		r.log.Error("rejected hook configuration, keeping the active one", "version", r.Version(), "error", err)
+
+		return err
+	}
+
+	previous := r.current.Swap(g)
+	r.succeeded.Add(1)
+	r.log.Info("reloaded hook configuration", "version", g.version, "previous", previous.version, "hooks", g.handlers.Chain.Names())
+
+	if previous.handlers.Close != nil {
+		time.AfterFunc(retireDelay, previous.handlers.Close)
+	}
+
+	return nil
+}
+
+// Watch reloads the configuration on SIGHUP, and when watchFiles is set, when its sources change. Files are not
+// watched when the watcher can't be created. It returns when the context is done.
+func (r *Reloader) Watch(ctx context.Context, watchFiles bool) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	// the file channels stay nil, and never ready, when files aren't watched
+	var watcher *fsnotify.Watcher
+	var events chan fsnotify.Event
+	var errs chan error
+	if watchFiles {
+		var err error
+		if watcher, err = fsnotify.NewWatcher(); err != nil {
+			// keep reloading on SIGHUP, stopping the signal notifications would let SIGHUP end the process
+			r.log.Error("failed to watch hook configuration files, only reloading on SIGHUP", "error", err)
+		} else {
+			defer watcher.Close()
+			r.watch(watcher)
+			events, errs = watcher.Events, watcher.Errors
+		}
+	}
+
+	changed := make(chan struct{}, 1)
+	var timer *time.Timer
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			r.log.Info("received SIGHUP, reloading hook configuration")
+		case <-changed:
+			r.log.Info("hook configuration changed, reloading")
+		case event := <-events:
+			if r.isSource(event.Name) {
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(debounce, func() {
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				})
+			}
+
+			continue
+		case err := <-errs:
+			r.log.Warn("failed to watch hook configuration", "error", err)
+
+			continue
+		}
+
+		if err := r.Reload(ctx); err == nil && watcher != nil {
+			r.watch(watcher)
+		}
+	}
+}
+
+// watch adds the sources of the active generation to the watcher. Files are watched through their directory,
+// to keep watching them when they are replaced.
+func (r *Reloader) watch(watcher *fsnotify.Watcher) {
+	for _, source := range r.current.Load().handlers.Sources {
+		dir := source
+		if info, err := os.Stat(source); err != nil || !info.IsDir() {
+			dir = filepath.Dir(source)
+		}
+		if err := watcher.Add(dir); err != nil {
+			r.log.Warn("failed to watch hook configuration", "path", dir, "error", err)
+		}
+	}
+}
+
+// isSource returns true when the path is a source of the active generation, or is inside a source directory.
+func (r *Reloader) isSource(path string) bool {
+	path = filepath.Clean(path)
+	for _, source := range r.current.Load().handlers.Sources {
+		source = filepath.Clean(source)
+		if path == source || strings.HasPrefix(path, source+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Reloader) load(ctx context.Context) (*generation, error) {
+	handlers, err := r.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := digest(handlers.Sources)
+	if err != nil {
+		if handlers.Close != nil {
+			handlers.Close()
+		}
+
+		return nil, err
+	}
+
+	return &generation{handlers: handlers, version: version, loadedAt: time.Now()}, nil
+}
+
+// digest hashes the names and the content of the source files, and of the files of the source directories.
+func digest(sources []string) (string, error) {
+	var files []string
+	for _, source := range sources {
+		err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	// sources can be read by several hooks
+	sort.Strings(files)
+	files = slices.Compact(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(hash, "%s\x00%d\x00", file, len(content))
+		_, _ = hash.Write(content)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
+
+// Close releases the active generation.
+func (r *Reloader) Close() {
+	if g := r.current.Load(); g.handlers.Close != nil {
+		g.handlers.Close()
+	}
+}
+
+func (r *Reloader) Name() string {
+	return "reloadable"
+}
+
+func (r *Reloader) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	return r.active(ctx).OnSessionCreated(ctx, session)
+}
+
+func (r *Reloader) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	return r.active(ctx).OnSessionUpdated(ctx, request)
+}
+
+func (r *Reloader) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	return r.active(ctx).OnSessionDeleted(ctx, session)
+}
+
+func (r *Reloader) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	return r.active(ctx).OnPartyCreated(ctx, session)
+}
+
+func (r *Reloader) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	return r.active(ctx).OnPartyUpdated(ctx, request)
+}
+
+func (r *Reloader) OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error {
+	return r.active(ctx).OnPartyDeleted(ctx, session)
+}
+
+// active returns the chain of the active generation and records its version in the current span.
+func (r *Reloader) active(ctx context.Context) *hook.Chain {
+	g := r.current.Load()
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("config.version", g.version))
+
+	return g.handlers.Chain
+}
+
+// Describe implements prometheus.Collector.
+func (r *Reloader) Describe(ch chan<- *prometheus.Desc) {
+	ch <- configInfoDesc
+	ch <- configLoadedDesc
+	ch <- configReloadsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (r *Reloader) Collect(ch chan<- prometheus.Metric) {
+	g := r.current.Load()
+	ch <- prometheus.MustNewConstMetric(configInfoDesc, prometheus.GaugeValue, 1, g.version)
+	ch <- prometheus.MustNewConstMetric(configLoadedDesc, prometheus.GaugeValue, float64(g.loadedAt.Unix()))
+	ch <- prometheus.MustNewConstMetric(configReloadsDesc, prometheus.CounterValue, float64(r.succeeded.Load()), "success")
+	ch <- prometheus.MustNewConstMetric(configReloadsDesc, prometheus.CounterValue, float64(r.failed.Load()), "failure")
+}
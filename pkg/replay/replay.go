@@ -0,0 +1,229 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/diff"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/journal"
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Options control how the records are replayed.
+type Options struct {
+	// Paced waits between calls as long as between the recorded calls, divided by Speed.
+	Paced bool
+	Speed float64
+	// Token is sent as a bearer authorization token when set.
+	Token string
+	// Timeout bounds each call.
+	Timeout time.Duration
+}
+
+// Mismatch is a replayed call whose outcome differs from the recorded one.
+type Mismatch struct {
+	Index    int
+	Method   string
+	TraceID  string
+	Expected string
+	Actual   string
+	// Changes describes the session differences when both calls returned a session.
+	Changes string
+}
+
+// Report summarizes a replay.
+type Report struct {
+	Sent       int
+	Matched    int
+	Skipped    int
+	Mismatches []Mismatch
+}
+
+// Run replays the records of the journal directory, in order, against the server of the connection.
+func Run(ctx context.Context, conn grpc.ClientConnInterface, journalDir string, options Options) (Report, error) {
+	var report Report
+	if options.Speed <= 0 {
+		options.Speed = 1
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+options.Token)
+	}
+
+	var previous time.Time
+	index := 0
+	err := journal.Iterate(journalDir, func(record journal.Record) error {
+		index++
+		if options.Paced && !previous.IsZero() {
+			if wait := time.Duration(float64(record.Timestamp.Sub(previous)) / options.Speed); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		previous = record.Timestamp
+
+		mismatch, sent, err := replayRecord(ctx, conn, record, options.Timeout)
+		if err != nil {
+			return fmt.Errorf("record %d (%s): %w", index, record.Method, err)
+		}
+		if !sent {
+			report.Skipped++
+
+			return nil
+		}
+
+		report.Sent++
+		if mismatch == nil {
+			report.Matched++
+		} else {
+			mismatch.Index = index
+			report.Mismatches = append(report.Mismatches, *mismatch)
+		}
+
+		return nil
+	})
+
+	return report, err
+}
+
+func replayRecord(ctx context.Context, conn grpc.ClientConnInterface, record journal.Record, timeout time.Duration) (*Mismatch, bool, error) {
+	method, err := findMethod(record.Method)
+	if err != nil {
+		return nil, false, nil //nolint:nilerr // records of unknown methods are skipped
+	}
+
+	request, err := newMessage(method.Input())
+	if err != nil {
+		return nil, false, err
+	}
+	if err = protojson.Unmarshal(record.Request, request); err != nil {
+		return nil, false, fmt.Errorf("invalid recorded request: %w", err)
+	}
+
+	response, err := newMessage(method.Output())
+	if err != nil {
+		return nil, false, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	callErr := conn.Invoke(callCtx, record.Method, request, response)
+
+	mismatch := &Mismatch{Method: record.Method, TraceID: record.TraceID}
+	recordedCode := codes.OK.String()
+	if record.Code != "" {
+		recordedCode = record.Code
+	}
+	actualStatus := status.Convert(callErr)
+
+	if recordedCode != codes.OK.String() || actualStatus.Code() != codes.OK {
+		if recordedCode == actualStatus.Code().String() {
+			return nil, true, nil
+		}
+		mismatch.Expected = fmt.Sprintf("%s: %s", recordedCode, record.Error)
+		mismatch.Actual = fmt.Sprintf("%s: %s", actualStatus.Code(), actualStatus.Message())
+
+		return mismatch, true, nil
+	}
+
+	expected, err := newMessage(method.Output())
+	if err != nil {
+		return nil, false, err
+	}
+	if len(record.Response) > 0 {
+		if err = protojson.Unmarshal(record.Response, expected); err != nil {
+			return nil, false, fmt.Errorf("invalid recorded response: %w", err)
+		}
+	}
+
+	if proto.Equal(expected, response) {
+		return nil, true, nil
+	}
+
+	mismatch.Expected = protojson.Format(expected)
+	mismatch.Actual = protojson.Format(response)
+	mismatch.Changes = sessionChanges(expected, response)
+
+	return mismatch, true, nil
+}
+
+// WriteReport prints a human-readable mismatch report.
+func WriteReport(w io.Writer, report Report) {
+	for _, mismatch := range report.Mismatches {
+		fmt.Fprintf(w, "MISMATCH #%d %s (trace %s)\n", mismatch.Index, mismatch.Method, mismatch.TraceID)
+		if mismatch.Changes != "" {
+			fmt.Fprintf(w, "  changes: %s\n", mismatch.Changes)
+		}
+		fmt.Fprintf(w, "  expected: %s\n", indent(mismatch.Expected))
+		fmt.Fprintf(w, "  actual:   %s\n", indent(mismatch.Actual))
+	}
+	fmt.Fprintf(w, "sent %d, matched %d, mismatched %d, skipped %d\n",
+		report.Sent, report.Matched, len(report.Mismatches), report.Skipped)
+}
+
+func sessionChanges(expected, actual proto.Message) string {
+	switch expectedResponse := expected.(type) {
+	case *sessionmanager.SessionResponse:
+		return diff.Sessions(expectedResponse.GetSession(), actual.(*sessionmanager.SessionResponse).GetSession()).String()
+	case *sessionmanager.PartyResponse:
+		return diff.Parties(expectedResponse.GetSession(), actual.(*sessionmanager.PartyResponse).GetSession()).String()
+	default:
+		return ""
+	}
+}
+
+func findMethod(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	serviceName, methodName, found := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !found {
+		return nil, fmt.Errorf("invalid method %s", fullMethod)
+	}
+
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, err
+	}
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("unknown method %s", fullMethod)
+	}
+
+	return method, nil
+}
+
+func newMessage(descriptor protoreflect.MessageDescriptor) (proto.Message, error) {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(descriptor.FullName())
+	if err != nil {
+		return nil, err
+	}
+
+	return messageType.New().Interface(), nil
+}
+
+func indent(text string) string {
+	return strings.ReplaceAll(text, "\n", "\n            ")
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package route
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/bundle"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/hook"
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TargetSession = "session"
+	TargetParty   = "party"
+
+	// Unmatched is the route of the sessions matching no route.
+	Unmatched = "unmatched"
+)
+
+// Config is the content of a routes file.
+type Config struct {
+	// Routes are evaluated in order, the first route matching a session handles it.
+	Routes []Route `yaml:"routes" json:"routes"`
+	// Unmatched handles the sessions matching no route. The sessions are left unchanged when it is not set.
+	Unmatched *bundle.Bundle `yaml:"unmatched" json:"unmatched"`
+}
+
+// Route maps session configuration names to a set of handlers.
+type Route struct {
+	Name string `yaml:"name" json:"name"`
+	// Target restricts the route to game sessions ("session") or parties ("party"). Empty matches both.
+	Target string `yaml:"target" json:"target"`
+	// ConfigurationNames are path.Match glob patterns of BaseSession.configuration_name, e.g. "ranked-*".
+	ConfigurationNames []string      `yaml:"configurationNames" json:"configurationNames"`
+	Handlers           bundle.Bundle `yaml:"handlers" json:"handlers"`
+}
+
+var (
+	callsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "session_manager_route_calls_total",
+		Help: "Number of callbacks handled by each configuration name route",
+	}, []string{"route", "callback", "result"})
+	callDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "session_manager_route_duration_seconds",
+		Help:    "Duration of the callbacks handled by each configuration name route",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "callback"})
+)
+
+// Table is a hook passing every event to the handlers of the route matching the session configuration name.
+// Update events are routed by their new session.
+type Table struct {
+	routes    []route
+	unmatched *hook.Chain
+}
+
+type route struct {
+	name     string
+	target   string
+	patterns []string
+	chain    *hook.Chain
+}
+
+func LoadFile(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse routes file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// NewTable validates the routes and builds their handlers with the builder.
+func NewTable(ctx context.Context, config Config, builder *bundle.Builder) (*Table, error) {
+	table := &Table{unmatched: hook.NewChain()}
+
+	names := map[string]bool{Unmatched: true}
+	for i, r := range config.Routes {
+		if r.Name == "" {
+			return nil, fmt.Errorf("route %d: missing name", i)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("route %s: duplicate name", r.Name)
+		}
+		names[r.Name] = true
+
+		if r.Target != "" && r.Target != TargetSession && r.Target != TargetParty {
+			return nil, fmt.Errorf("route %s: unknown target %q", r.Name, r.Target)
+		}
+		if len(r.ConfigurationNames) == 0 {
+			return nil, fmt.Errorf("route %s: missing configuration names", r.Name)
+		}
+		for _, pattern := range r.ConfigurationNames {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("route %s: invalid pattern %q: %w", r.Name, pattern, err)
+			}
+		}
+
+		chain, err := builder.Build(ctx, r.Handlers)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", r.Name, err)
+		}
+		table.routes = append(table.routes, route{name: r.Name, target: r.Target, patterns: r.ConfigurationNames, chain: chain})
+	}
+
+	if config.Unmatched != nil {
+		chain, err := builder.Build(ctx, *config.Unmatched)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", Unmatched, err)
+		}
+		table.unmatched = chain
+	}
+
+	return table, nil
+}
+
+// Routes returns the route names, in evaluation order.
+func (t *Table) Routes() []string {
+	names := make([]string, len(t.routes))
+	for i, r := range t.routes {
+		names[i] = r.name
+	}
+
+	return names
+}
+
+// Match returns the name and the handlers of the route of a session, or of the unmatched route.
+func (t *Table) Match(target string, session *sessionmanager.BaseSession) (string, *hook.Chain) {
+	for _, r := range t.routes {
+		if r.target != "" && r.target != target {
+			continue
+		}
+		for _, pattern := range r.patterns {
+			if matched, _ := path.Match(pattern, session.GetConfigurationName()); matched {
+				return r.name, r.chain
+			}
+		}
+	}
+
+	return Unmatched, t.unmatched
+}
+
+func (t *Table) Name() string {
+	return "configuration-routes"
+}
+
+func (t *Table) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	name, chain := t.route(ctx, TargetSession, session.GetSession())
+	start := time.Now()
+
+	result, err := chain.OnSessionCreated(ctx, session)
+	t.observe(name, "OnSessionCreated", start, err)
+
+	return result, err
+}
+
+func (t *Table) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	name, chain := t.route(ctx, TargetSession, request.GetSessionNew().GetSession())
+	start := time.Now()
+
+	err := chain.OnSessionUpdated(ctx, request)
+	t.observe(name, "OnSessionUpdated", start, err)
+
+	return err
+}
+
+func (t *Table) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	name, chain := t.route(ctx, TargetSession, session.GetSession())
+	start := time.Now()
+
+	err := chain.OnSessionDeleted(ctx, session)
+	t.observe(name, "OnSessionDeleted", start, err)
+
+	return err
+}
+
+func (t *Table) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	name, chain := t.route(ctx, TargetParty, session.GetSession())
+	start := time.Now()
+
+	result, err := chain.OnPartyCreated(ctx, session)
+	t.observe(name, "OnPartyCreated", start, err)
+
+	return result, err
+}
+
+func (t *Table) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	name, chain := t.route(ctx, TargetParty, request.GetSessionNew().GetSession())
+	start := time.Now()
+
+	err := chain.OnPartyUpdated(ctx, request)
+	t.observe(name, "OnPartyUpdated", start, err)
+
+	return err
+}
+
+func (t *Table) OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error {
+	name, chain := t.route(ctx, TargetParty, session.GetSession())
+	start := time.Now()
+
+	err := chain.OnPartyDeleted(ctx, session)
+	t.observe(name, "OnPartyDeleted", start, err)
+
+	return err
+}
+
+// Collectors returns the metrics of the calls of each route, shared by every table.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{callsTotal, callDuration}
+}
+
+// route matches the session and records the route in the current span.
+func (t *Table) route(ctx context.Context, target string, session *sessionmanager.BaseSession) (string, *hook.Chain) {
+	name, chain := t.Match(target, session)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("session.route", name))
+
+	return name, chain
+}
+
+func (t *Table) observe(name string, callback string, start time.Time, err error) {
+	callDuration.WithLabelValues(name, callback).Observe(time.Since(start).Seconds())
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	callsTotal.WithLabelValues(name, callback, result).Inc()
+}
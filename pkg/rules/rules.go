@@ -0,0 +1,226 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/utils"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TargetSession = "session"
+	TargetParty   = "party"
+)
+
+// Config is the content of an attribute rules file. JSON files are accepted as well since JSON is valid YAML.
+type Config struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule injects attributes into sessions matching its criteria.
+type Rule struct {
+	Name string `yaml:"name" json:"name"`
+	// Target is either "session", "party", or empty to apply to both.
+	Target string `yaml:"target" json:"target"`
+	Match  Match  `yaml:"match" json:"match"`
+	// Set adds attributes that are not present yet.
+	Set map[string]interface{} `yaml:"set" json:"set"`
+	// Overwrite adds attributes, replacing any existing value.
+	Overwrite map[string]interface{} `yaml:"overwrite" json:"overwrite"`
+	// Delete removes attributes.
+	Delete []string `yaml:"delete" json:"delete"`
+}
+
+// Match holds the criteria of a Rule. Empty criteria match everything.
+type Match struct {
+	ConfigurationNames []string `yaml:"configurationNames" json:"configurationNames"`
+	MatchPools         []string `yaml:"matchPools" json:"matchPools"`
+	Namespaces         []string `yaml:"namespaces" json:"namespaces"`
+	MinMembers         *int     `yaml:"minMembers" json:"minMembers"`
+	MaxMembers         *int     `yaml:"maxMembers" json:"maxMembers"`
+}
+
+type compiledRule struct {
+	Rule
+	set       map[string]*structpb.Value
+	overwrite map[string]*structpb.Value
+}
+
+// Engine applies attribute rules, in file order, to created sessions and parties.
+type Engine struct {
+	rules []compiledRule
+}
+
+// DefaultConfig returns the sample rules used when no rules file is configured.
+func DefaultConfig() Config {
+	return Config{
+		Rules: []Rule{
+			{
+				Name:      "sample",
+				Target:    TargetSession,
+				Overwrite: map[string]interface{}{"SAMPLE": "value from GRPC server"},
+			},
+			{
+				Name:      "party-sample",
+				Target:    TargetParty,
+				Overwrite: map[string]interface{}{"PARTY_SAMPLE": "party value from GRPC server"},
+			},
+		},
+	}
+}
+
+// LoadFile reads an attribute rules file.
+func LoadFile(path string) (Config, error) {
+	var config Config
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("failed to parse attribute rules file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// NewEngine validates the rules of the config and returns an Engine applying them.
+func NewEngine(config Config) (*Engine, error) {
+	engine := &Engine{rules: make([]compiledRule, 0, len(config.Rules))}
+	for i, rule := range config.Rules {
+		if rule.Target != "" && rule.Target != TargetSession && rule.Target != TargetParty {
+			return nil, fmt.Errorf("rule %d (%s): unknown target %q", i, rule.Name, rule.Target)
+		}
+
+		set, err := toValues(rule.Set)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): invalid set: %w", i, rule.Name, err)
+		}
+
+		overwrite, err := toValues(rule.Overwrite)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): invalid overwrite: %w", i, rule.Name, err)
+		}
+
+		engine.rules = append(engine.rules, compiledRule{Rule: rule, set: set, overwrite: overwrite})
+	}
+
+	return engine, nil
+}
+
+// ApplySession applies the matching rules to a game session and returns the names of the applied rules.
+func (e *Engine) ApplySession(session *sessionmanager.GameSession) []string {
+	if session.GetSession() == nil {
+		return nil
+	}
+
+	return e.apply(TargetSession, session.GetMatchPool(), session.Session)
+}
+
+// ApplyParty applies the matching rules to a party session and returns the names of the applied rules.
+func (e *Engine) ApplyParty(session *sessionmanager.PartySession) []string {
+	if session.GetSession() == nil {
+		return nil
+	}
+
+	return e.apply(TargetParty, "", session.Session)
+}
+
+func (e *Engine) apply(target string, matchPool string, session *sessionmanager.BaseSession) []string {
+	var applied []string
+	for _, rule := range e.rules {
+		if rule.Target != "" && rule.Target != target {
+			continue
+		}
+		if !rule.Match.matches(target, matchPool, session) {
+			continue
+		}
+
+		attributes := utils.EnsureStruct(&session.Attributes)
+		for key, value := range rule.set {
+			if _, found := attributes.Fields[key]; !found {
+				attributes.Fields[key] = proto.Clone(value).(*structpb.Value)
+			}
+		}
+		for key, value := range rule.overwrite {
+			attributes.Fields[key] = proto.Clone(value).(*structpb.Value)
+		}
+		for _, key := range rule.Delete {
+			delete(attributes.Fields, key)
+		}
+
+		applied = append(applied, rule.Name)
+	}
+
+	return applied
+}
+
+func (m Match) matches(target string, matchPool string, session *sessionmanager.BaseSession) bool {
+	if len(m.ConfigurationNames) > 0 && !slices.Contains(m.ConfigurationNames, session.GetConfigurationName()) {
+		return false
+	}
+
+	// parties are not matchmade, so a match pool criteria never matches them
+	if len(m.MatchPools) > 0 && (target != TargetSession || !slices.Contains(m.MatchPools, matchPool)) {
+		return false
+	}
+
+	if len(m.Namespaces) > 0 && !slices.Contains(m.Namespaces, session.GetNamespace()) {
+		return false
+	}
+
+	members := len(session.GetMembers())
+	if m.MinMembers != nil && members < *m.MinMembers {
+		return false
+	}
+	if m.MaxMembers != nil && members > *m.MaxMembers {
+		return false
+	}
+
+	return true
+}
+
+func toValues(attributes map[string]interface{}) (map[string]*structpb.Value, error) {
+	values := make(map[string]*structpb.Value, len(attributes))
+	for key, attribute := range attributes {
+		value, err := structpb.NewValue(attribute)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", key, err)
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+func (e *Engine) Name() string {
+	return "attribute-rules"
+}
+
+func (e *Engine) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	if applied := e.ApplySession(session); len(applied) > 0 {
+		slog.Default().Info("applied attribute rules", "session", session.GetSession().GetId(), "rules", applied)
+	}
+
+	return session, nil
+}
+
+func (e *Engine) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	if applied := e.ApplyParty(session); len(applied) > 0 {
+		slog.Default().Info("applied attribute rules", "party", session.GetSession().GetId(), "rules", applied)
+	}
+
+	return session, nil
+}
@@ -0,0 +1,454 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TargetSession = "session"
+	TargetParty   = "party"
+
+	// ModeReject refuses the created sessions with invalid fields.
+	ModeReject = "reject"
+	// ModeStrip removes the invalid keys of the created sessions, and refuses them when they are still invalid.
+	ModeStrip = "strip"
+
+	FieldAttributes              = "attributes"
+	FieldStorages                = "storages"
+	FieldConfigurationAttributes = "configuration.attributes"
+
+	violationType = "SESSION_SCHEMA"
+)
+
+// Config is the content of a schemas file.
+type Config struct {
+	// Mode applies to the schemas without a mode of their own, "reject" by default.
+	Mode string `yaml:"mode" json:"mode"`
+	// Schemas are evaluated in order, the first schema matching a session validates it.
+	Schemas []Schema `yaml:"schemas" json:"schemas"`
+}
+
+// Schema holds the JSON Schemas of the free-form fields of the sessions of some configuration names.
+// A field is not validated when its schema is not set.
+type Schema struct {
+	Name string `yaml:"name" json:"name"`
+	// Target restricts the schema to game sessions ("session") or parties ("party"). Empty matches both.
+	Target string `yaml:"target" json:"target"`
+	// ConfigurationNames are path.Match glob patterns of BaseSession.configuration_name, e.g. "ranked-*".
+	ConfigurationNames []string `yaml:"configurationNames" json:"configurationNames"`
+	// Mode is "reject" or "strip", it defaults to the mode of the file.
+	Mode string `yaml:"mode" json:"mode"`
+
+	Attributes              interface{} `yaml:"attributes" json:"attributes"`
+	Storages                interface{} `yaml:"storages" json:"storages"`
+	ConfigurationAttributes interface{} `yaml:"configurationAttributes" json:"configurationAttributes"`
+}
+
+var violationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "session_manager_schema_violations_total",
+	Help: "Number of sessions with fields failing their JSON Schema",
+}, []string{"schema", "field", "callback", "result"})
+
+// Validator is a hook validating the attributes and storages of the sessions against the schema of their
+// configuration name. Created sessions are rejected or stripped, updated sessions are only reported.
+type Validator struct {
+	schemas []compiled
+	log     *slog.Logger
+}
+
+type compiled struct {
+	name     string
+	target   string
+	patterns []string
+	mode     string
+	fields   map[string]*jsonschema.Schema
+}
+
+// Violation is a field value failing its schema.
+type Violation struct {
+	Field string
+	// Location is the JSON pointer of the invalid value in the field, e.g. "/region".
+	Location string
+	Message  string
+}
+
+func LoadFile(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse schemas file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// NewValidator validates the configuration and compiles its schemas.
+func NewValidator(config Config) (*Validator, error) {
+	defaultMode := config.Mode
+	if defaultMode == "" {
+		defaultMode = ModeReject
+	}
+	if defaultMode != ModeReject && defaultMode != ModeStrip {
+		return nil, fmt.Errorf("unknown mode %q", defaultMode)
+	}
+
+	validator := &Validator{log: slog.Default()}
+	names := map[string]bool{}
+	for i, s := range config.Schemas {
+		if s.Name == "" {
+			return nil, fmt.Errorf("schema %d: missing name", i)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("schema %s: duplicate name", s.Name)
+		}
+		names[s.Name] = true
+
+		if s.Target != "" && s.Target != TargetSession && s.Target != TargetParty {
+			return nil, fmt.Errorf("schema %s: unknown target %q", s.Name, s.Target)
+		}
+		if len(s.ConfigurationNames) == 0 {
+			return nil, fmt.Errorf("schema %s: missing configuration names", s.Name)
+		}
+		for _, pattern := range s.ConfigurationNames {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("schema %s: invalid pattern %q: %w", s.Name, pattern, err)
+			}
+		}
+
+		mode := s.Mode
+		if mode == "" {
+			mode = defaultMode
+		}
+		if mode != ModeReject && mode != ModeStrip {
+			return nil, fmt.Errorf("schema %s: unknown mode %q", s.Name, mode)
+		}
+
+		c := compiled{name: s.Name, target: s.Target, patterns: s.ConfigurationNames, mode: mode, fields: map[string]*jsonschema.Schema{}}
+		for field, document := range map[string]interface{}{
+			FieldAttributes:              s.Attributes,
+			FieldStorages:                s.Storages,
+			FieldConfigurationAttributes: s.ConfigurationAttributes,
+		} {
+			if document == nil {
+				continue
+			}
+			compiledSchema, err := compile(fmt.Sprintf("mem:///%s/%s.json", s.Name, field), document)
+			if err != nil {
+				return nil, fmt.Errorf("schema %s: %s: %w", s.Name, field, err)
+			}
+			c.fields[field] = compiledSchema
+		}
+		validator.schemas = append(validator.schemas, c)
+	}
+
+	return validator, nil
+}
+
+// compile compiles a schema decoded from YAML, defaulting to the 2020-12 draft.
+func compile(url string, document interface{}) (*jsonschema.Schema, error) {
+	content, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err = compiler.AddResource(url, bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile(url)
+}
+
+// Schemas returns the schema names, in evaluation order.
+func (v *Validator) Schemas() []string {
+	names := make([]string, len(v.schemas))
+	for i, s := range v.schemas {
+		names[i] = s.name
+	}
+
+	return names
+}
+
+// Validate returns the violations of the session fields, and the name of the schema that validated them.
+// The name is empty when no schema matches the session.
+func (v *Validator) Validate(target string, session *sessionmanager.BaseSession) (string, []Violation) {
+	s := v.match(target, session)
+	if s == nil {
+		return "", nil
+	}
+
+	var violations []Violation
+	for _, field := range sortedFields(s) {
+		violations = append(violations, validate(s.fields[field], field, fieldOf(session, field))...)
+	}
+
+	return s.name, violations
+}
+
+func (v *Validator) match(target string, session *sessionmanager.BaseSession) *compiled {
+	for i, s := range v.schemas {
+		if s.target != "" && s.target != target {
+			continue
+		}
+		for _, pattern := range s.patterns {
+			if matched, _ := path.Match(pattern, session.GetConfigurationName()); matched {
+				return &v.schemas[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) Name() string {
+	return "schema"
+}
+
+func (v *Validator) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	if err := v.admit(TargetSession, "OnSessionCreated", session.GetSession()); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (v *Validator) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	v.report(TargetSession, "OnSessionUpdated", request.GetSessionNew().GetSession())
+
+	return nil
+}
+
+func (v *Validator) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	if err := v.admit(TargetParty, "OnPartyCreated", session.GetSession()); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (v *Validator) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	v.report(TargetParty, "OnPartyUpdated", request.GetSessionNew().GetSession())
+
+	return nil
+}
+
+// Collectors returns the metrics of the violations, shared by every validator.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{violationsTotal}
+}
+
+// admit validates a created session, stripping its invalid keys in strip mode. It returns a FailedPrecondition
+// status error when the session is still invalid.
+func (v *Validator) admit(target string, callback string, session *sessionmanager.BaseSession) error {
+	s := v.match(target, session)
+	if s == nil {
+		return nil
+	}
+
+	var violations []Violation
+	for _, field := range sortedFields(s) {
+		fieldViolations := validate(s.fields[field], field, fieldOf(session, field))
+		if len(fieldViolations) == 0 {
+			continue
+		}
+
+		if s.mode == ModeStrip {
+			stripped := strip(s.fields[field], fieldOf(session, field), fieldViolations)
+			if fieldViolations = validate(s.fields[field], field, fieldOf(session, field)); len(fieldViolations) == 0 {
+				violationsTotal.WithLabelValues(s.name, field, callback, "stripped").Inc()
+				v.log.Warn("stripped invalid session keys", "session", session.GetId(), "configuration", session.GetConfigurationName(),
+					"schema", s.name, "field", field, "keys", stripped)
+
+				continue
+			}
+		}
+
+		violationsTotal.WithLabelValues(s.name, field, callback, "rejected").Inc()
+		violations = append(violations, fieldViolations...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	v.log.Warn("rejected session failing its schema", "session", session.GetId(), "configuration", session.GetConfigurationName(),
+		"schema", s.name, "violations", describe(violations))
+
+	details := make([]*errdetails.PreconditionFailure_Violation, len(violations))
+	for i, violation := range violations {
+		details[i] = &errdetails.PreconditionFailure_Violation{
+			Type:        violationType,
+			Subject:     violation.Field + violation.Location,
+			Description: violation.Message,
+		}
+	}
+	st := status.New(codes.FailedPrecondition, fmt.Sprintf("session configuration %q rejected: %s",
+		session.GetConfigurationName(), strings.Join(describe(violations), "; ")))
+	if detailed, err := st.WithDetails(&errdetails.PreconditionFailure{Violations: details}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+// report logs and counts the violations of an updated session, which is left unchanged.
+func (v *Validator) report(target string, callback string, session *sessionmanager.BaseSession) {
+	name, violations := v.Validate(target, session)
+	if len(violations) == 0 {
+		return
+	}
+
+	fields := map[string]bool{}
+	for _, violation := range violations {
+		if !fields[violation.Field] {
+			fields[violation.Field] = true
+			violationsTotal.WithLabelValues(name, violation.Field, callback, "reported").Inc()
+		}
+	}
+	v.log.Warn("updated session fails its schema", "session", session.GetId(), "version", session.GetVersion(),
+		"configuration", session.GetConfigurationName(), "schema", name, "violations", describe(violations))
+}
+
+func sortedFields(s *compiled) []string {
+	fields := make([]string, 0, len(s.fields))
+	for field := range s.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// fieldOf returns the struct of a session field, an empty one when the session doesn't have it.
+func fieldOf(session *sessionmanager.BaseSession, field string) *structpb.Struct {
+	var value *structpb.Struct
+	switch field {
+	case FieldAttributes:
+		value = session.GetAttributes()
+	case FieldStorages:
+		value = session.GetStorages()
+	case FieldConfigurationAttributes:
+		value = session.GetConfiguration().GetAttributes()
+	}
+	if value == nil {
+		return &structpb.Struct{}
+	}
+
+	return value
+}
+
+func validate(s *jsonschema.Schema, field string, value *structpb.Struct) []Violation {
+	err := s.Validate(value.AsMap())
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []Violation{{Field: field, Message: err.Error()}}
+	}
+
+	var violations []Violation
+	for _, leaf := range leaves(validationErr) {
+		violations = append(violations, Violation{Field: field, Location: leaf.InstanceLocation, Message: leaf.Message})
+	}
+
+	return violations
+}
+
+// leaves returns the innermost errors, the ones pointing at the invalid values.
+func leaves(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var result []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		result = append(result, leaves(cause)...)
+	}
+
+	return result
+}
+
+// strip removes the top level keys holding invalid values, and the keys not allowed by the schema when it sets
+// additionalProperties to false. It returns the removed keys.
+func strip(s *jsonschema.Schema, value *structpb.Struct, violations []Violation) []string {
+	var stripped []string
+	remove := func(key string) {
+		if _, found := value.Fields[key]; found {
+			delete(value.Fields, key)
+			stripped = append(stripped, key)
+		}
+	}
+
+	for _, violation := range violations {
+		if key, _, _ := strings.Cut(strings.TrimPrefix(violation.Location, "/"), "/"); key != "" {
+			remove(unescape(key))
+		}
+	}
+
+	for s.Ref != nil {
+		s = s.Ref
+	}
+	if allowed, ok := s.AdditionalProperties.(bool); ok && !allowed {
+		for key := range value.Fields {
+			if !declared(s, key) {
+				remove(key)
+			}
+		}
+	}
+	sort.Strings(stripped)
+
+	return stripped
+}
+
+func declared(s *jsonschema.Schema, key string) bool {
+	if _, found := s.Properties[key]; found {
+		return true
+	}
+	for pattern := range s.PatternProperties {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unescape decodes a JSON pointer token.
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+func describe(violations []Violation) []string {
+	descriptions := make([]string, len(violations))
+	for i, violation := range violations {
+		descriptions[i] = fmt.Sprintf("%s%s: %s", violation.Field, violation.Location, violation.Message)
+	}
+
+	return descriptions
+}
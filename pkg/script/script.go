@@ -0,0 +1,317 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	starlarkjson "go.starlark.net/lib/json"
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const scriptSuffix = ".star"
+
+// Functions a script can define, each invoked by the callback of the same name.
+const (
+	onSessionCreated = "on_session_created"
+	onSessionUpdated = "on_session_updated"
+	onSessionDeleted = "on_session_deleted"
+	onPartyCreated   = "on_party_created"
+	onPartyUpdated   = "on_party_updated"
+	onPartyDeleted   = "on_party_deleted"
+)
+
+var (
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true}
+	unmarshalOptions = protojson.UnmarshalOptions{}
+)
+
+// Config specifies where scripts are loaded from and how they are sandboxed.
+type Config struct {
+	Dir string
+	// MaxSteps bounds the Starlark computation steps of a single call. 0 means unlimited.
+	MaxSteps uint64
+	// Timeout bounds the duration of a single call. 0 means unlimited.
+	Timeout time.Duration
+}
+
+// Script is a Starlark file defining some of the on_session_created, on_session_updated, on_session_deleted,
+// on_party_created, on_party_updated and on_party_deleted functions. Each is called with the sessions as dictionaries
+// of the protobuf JSON form, using the proto field names, e.g. session["session"]["attributes"].
+//
+// Created functions can modify the session dictionary in place or return a new one. Scripts reject a session by
+// calling reject(message), and can only use the json and math modules: they can't load other files or do any I/O.
+type Script struct {
+	name    string
+	config  Config
+	globals starlark.StringDict
+	log     *slog.Logger
+}
+
+// rejection is the error raised by the reject builtin.
+type rejection struct {
+	message string
+}
+
+func (r *rejection) Error() string {
+	return r.message
+}
+
+// LoadDir compiles and runs the top level of every .star file of the directory, in file name order.
+// The first file failing to compile or run is reported.
+func LoadDir(config Config) ([]*Script, error) {
+	entries, err := os.ReadDir(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), scriptSuffix) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	scripts := make([]*Script, 0, len(names))
+	for _, name := range names {
+		src, err := os.ReadFile(filepath.Join(config.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+		script, err := Load(config, strings.TrimSuffix(name, scriptSuffix), src)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+
+	return scripts, nil
+}
+
+// Load compiles and runs the top level of a script.
+func Load(config Config, name string, src []byte) (*Script, error) {
+	script := &Script{
+		name:   name,
+		config: config,
+		log:    slog.Default().With("script", name),
+	}
+
+	thread := script.newThread(name)
+	globals, err := starlark.ExecFile(thread, name+scriptSuffix, src, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load script %s: %w", name, describe(err))
+	}
+	// frozen globals can be shared by concurrent calls
+	globals.Freeze()
+	script.globals = globals
+
+	return script, nil
+}
+
+// Functions returns the names of the callback functions the script defines.
+func (s *Script) Functions() []string {
+	var functions []string
+	for _, name := range []string{onSessionCreated, onSessionUpdated, onSessionDeleted, onPartyCreated, onPartyUpdated, onPartyDeleted} {
+		if _, ok := s.globals[name].(starlark.Callable); ok {
+			functions = append(functions, name)
+		}
+	}
+
+	return functions
+}
+
+func (s *Script) Name() string {
+	return "script:" + s.name
+}
+
+func (s *Script) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	result := &sessionmanager.GameSession{}
+	if err := s.modify(ctx, onSessionCreated, session, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Script) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	return s.call(ctx, onSessionUpdated, request.GetSessionOld(), request.GetSessionNew())
+}
+
+func (s *Script) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	return s.call(ctx, onSessionDeleted, session)
+}
+
+func (s *Script) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	result := &sessionmanager.PartySession{}
+	if err := s.modify(ctx, onPartyCreated, session, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Script) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	return s.call(ctx, onPartyUpdated, request.GetSessionOld(), request.GetSessionNew())
+}
+
+func (s *Script) OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error {
+	return s.call(ctx, onPartyDeleted, session)
+}
+
+// modify calls the function with the session, and unmarshals the resulting dictionary into result.
+// The session is returned unchanged when the script doesn't define the function.
+func (s *Script) modify(ctx context.Context, function string, session proto.Message, result proto.Message) error {
+	fn, ok := s.globals[function].(starlark.Callable)
+	if !ok {
+		proto.Merge(result, session)
+
+		return nil
+	}
+
+	arg, err := toValue(session)
+	if err != nil {
+		return err
+	}
+	value, err := s.run(ctx, function, fn, arg)
+	if err != nil {
+		return err
+	}
+	if value == starlark.None {
+		value = arg
+	}
+
+	return fromValue(value, result)
+}
+
+// call calls the function with the sessions, ignoring its result.
+func (s *Script) call(ctx context.Context, function string, sessions ...proto.Message) error {
+	fn, ok := s.globals[function].(starlark.Callable)
+	if !ok {
+		return nil
+	}
+
+	args := make(starlark.Tuple, len(sessions))
+	for i, session := range sessions {
+		arg, err := toValue(session)
+		if err != nil {
+			return err
+		}
+		args[i] = arg
+	}
+	_, err := s.run(ctx, function, fn, args...)
+
+	return err
+}
+
+// run calls fn on a new thread, cancelling it when the timeout elapses or the context is done.
+func (s *Script) run(ctx context.Context, function string, fn starlark.Callable, args ...starlark.Value) (starlark.Value, error) {
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+
+	thread := s.newThread(function)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	value, err := starlark.Call(thread, fn, args, nil)
+	if err != nil {
+		var rejected *rejection
+		if errors.As(err, &rejected) {
+			return nil, status.Error(codes.FailedPrecondition, rejected.message)
+		}
+
+		return nil, fmt.Errorf("%s: %w", function, describe(err))
+	}
+
+	return value, nil
+}
+
+func (s *Script) newThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: s.name + "/" + name,
+		Print: func(_ *starlark.Thread, msg string) {
+			s.log.Info(msg)
+		},
+		// Load is left nil, so load statements fail
+	}
+	thread.SetMaxExecutionSteps(s.config.MaxSteps)
+
+	return thread
+}
+
+var predeclared = starlark.StringDict{
+	"json":   starlarkjson.Module,
+	"math":   starlarkmath.Module,
+	"reject": starlark.NewBuiltin("reject", reject),
+}
+
+func reject(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var message string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &message); err != nil {
+		return nil, err
+	}
+
+	return nil, &rejection{message: message}
+}
+
+// toValue converts a message to a mutable dictionary through its JSON form.
+func toValue(message proto.Message) (starlark.Value, error) {
+	data, err := marshalOptions.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return starlark.Call(&starlark.Thread{}, starlarkjson.Module.Members["decode"], starlark.Tuple{starlark.String(data)}, nil)
+}
+
+func fromValue(value starlark.Value, message proto.Message) error {
+	if _, ok := value.(*starlark.Dict); !ok {
+		return fmt.Errorf("script returned %s, want dict or None", value.Type())
+	}
+
+	data, err := starlark.Call(&starlark.Thread{}, starlarkjson.Module.Members["encode"], starlark.Tuple{value}, nil)
+	if err != nil {
+		return err
+	}
+	if err = unmarshalOptions.Unmarshal([]byte(data.(starlark.String)), message); err != nil {
+		return fmt.Errorf("script returned an invalid session: %w", err)
+	}
+
+	return nil
+}
+
+// describe includes the Starlark backtrace of evaluation errors.
+func describe(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+
+	return err
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"context"
+	"log"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/actions"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/diff"
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/state"
+)
+
+// NewSampleActionDispatcher returns a dispatcher with sample handlers of a few update actions.
+func NewSampleActionDispatcher() *actions.Dispatcher {
+	dispatcher := actions.NewDispatcher()
+	dispatcher.HandleSession(sessionmanager.Action_SessionUserJoined, onUserJoined)
+	dispatcher.HandleSession(sessionmanager.Action_DSStatusChanged, onDSStatusChanged)
+	dispatcher.HandleSession(sessionmanager.Action_SessionLeaderPromoted, onSessionLeaderPromoted)
+	dispatcher.HandleParty(sessionmanager.Action_SessionLeaderPromoted, onPartyLeaderPromoted)
+
+	return dispatcher
+}
+
+func onUserJoined(ctx context.Context, sessionOld, sessionNew *sessionmanager.GameSession) error {
+	log.Println("user joined game session", sessionNew.GetSession().GetId(), "members", len(sessionNew.GetSession().GetMembers()))
+
+	// the joined users are found in the changes of the update
+	if changes, ok := diff.FromContext(ctx); ok {
+		log.Println("game session", sessionNew.GetSession().GetId(), "added members", changes.MembersAdded,
+			"status changes", changes.StatusChanges)
+	}
+
+	// the parties placed in the teams are looked up in the live state
+	if store, ok := state.FromContext(ctx); ok {
+		for _, party := range store.PartiesOf(sessionNew) {
+			log.Println("game session", sessionNew.GetSession().GetId(), "has party", party.GetSession().GetId(),
+				"attributes", party.GetSession().GetAttributes().AsMap())
+		}
+	}
+
+	return nil
+}
+
+func onDSStatusChanged(ctx context.Context, sessionOld, sessionNew *sessionmanager.GameSession) error {
+	log.Println("DS status of game session", sessionNew.GetSession().GetId(), "changed from",
+		sessionOld.GetDsInformation().GetStatusV2(), "to", sessionNew.GetDsInformation().GetStatusV2())
+
+	return nil
+}
+
+func onSessionLeaderPromoted(ctx context.Context, sessionOld, sessionNew *sessionmanager.GameSession) error {
+	logLeaderPromoted(ctx, "game session", sessionNew.GetSession())
+
+	return nil
+}
+
+func onPartyLeaderPromoted(ctx context.Context, sessionOld, sessionNew *sessionmanager.PartySession) error {
+	logLeaderPromoted(ctx, "party session", sessionNew.GetSession())
+
+	return nil
+}
+
+func logLeaderPromoted(ctx context.Context, kind string, session *sessionmanager.BaseSession) {
+	if changes, ok := diff.FromContext(ctx); ok && changes.Leader != nil {
+		log.Println(kind, session.GetId(), "leader promoted from", changes.Leader.From, "to", changes.Leader.To)
+
+		return
+	}
+	log.Println(kind, session.GetId(), "leader promoted to", session.GetLeaderId())
+}
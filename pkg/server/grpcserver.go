@@ -8,26 +8,29 @@ import (
 	"context"
 	"log"
 
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/diff"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/hook"
 	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type SessionManager struct {
 	sessionmanager.UnimplementedSessionManagerServer
+	// Hooks are the modules invoked for every callback, in order.
+	Hooks *hook.Chain
 }
 
 func (s *SessionManager) OnSessionCreated(ctx context.Context, request *sessionmanager.SessionCreatedRequest) (*sessionmanager.SessionResponse, error) {
 	log.Println("got message from OnSessionCreated")
 	log.Println("game session", request.GetSession())
-	session := request.GetSession()
-	if session.Session.Attributes == nil {
-		session.Session.Attributes = &structpb.Struct{}
-	}
-	if session.Session.Attributes.Fields == nil {
-		session.Session.Attributes.Fields = map[string]*structpb.Value{}
+	session, err := s.Hooks.OnSessionCreated(ctx, request.GetSession())
+	if err != nil {
+		log.Println("game session rejected:", err)
+
+		return nil, err
 	}
-	session.Session.Attributes.Fields["SAMPLE"] = structpb.NewStringValue("value from GRPC server")
 	return &sessionmanager.SessionResponse{
 		Session: session,
 	}, nil
@@ -37,26 +40,38 @@ func (s *SessionManager) OnSessionUpdated(ctx context.Context, request *sessionm
 	log.Println("got message from OnSessionUpdated")
 	log.Println("old game Session:", request.GetSessionOld())
 	log.Println("new game Session:", request.GetSessionNew())
+	changes := diff.Sessions(request.GetSessionOld(), request.GetSessionNew())
+	log.Println("game session changes:", changes)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("session.changes", changes.String()))
+	ctx = diff.NewContext(ctx, changes)
+	if err := s.Hooks.OnSessionUpdated(ctx, request); err != nil {
+		log.Println("game session update failed:", err)
+
+		return nil, err
+	}
 	return &emptypb.Empty{}, nil
 }
 
 func (s *SessionManager) OnSessionDeleted(ctx context.Context, request *sessionmanager.SessionDeletedRequest) (*emptypb.Empty, error) {
 	log.Println("got message from OnSessionDeleted")
 	log.Println("session deleted", request.GetSession())
+	if err := s.Hooks.OnSessionDeleted(ctx, request.GetSession()); err != nil {
+		log.Println("game session deletion failed:", err)
+
+		return nil, err
+	}
 	return &emptypb.Empty{}, nil
 }
 
 func (s *SessionManager) OnPartyCreated(ctx context.Context, request *sessionmanager.PartyCreatedRequest) (*sessionmanager.PartyResponse, error) {
 	log.Println("got message from OnPartyCreated")
 	log.Println("party session", request.GetSession())
-	session := request.GetSession()
-	if session.Session.Attributes == nil {
-		session.Session.Attributes = &structpb.Struct{}
-	}
-	if session.Session.Attributes.Fields == nil {
-		session.Session.Attributes.Fields = map[string]*structpb.Value{}
+	session, err := s.Hooks.OnPartyCreated(ctx, request.GetSession())
+	if err != nil {
+		log.Println("party session rejected:", err)
+
+		return nil, err
 	}
-	session.Session.Attributes.Fields["PARTY_SAMPLE"] = structpb.NewStringValue("party value from GRPC server")
 	return &sessionmanager.PartyResponse{
 		Session: session,
 	}, nil
@@ -66,11 +81,25 @@ func (s *SessionManager) OnPartyUpdated(ctx context.Context, request *sessionman
 	log.Println("got message from OnPartyUpdated")
 	log.Println("old party session", request.GetSessionOld())
 	log.Println("new party session", request.GetSessionNew())
+	changes := diff.Parties(request.GetSessionOld(), request.GetSessionNew())
+	log.Println("party session changes:", changes)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("session.changes", changes.String()))
+	ctx = diff.NewContext(ctx, changes)
+	if err := s.Hooks.OnPartyUpdated(ctx, request); err != nil {
+		log.Println("party session update failed:", err)
+
+		return nil, err
+	}
 	return &emptypb.Empty{}, nil
 }
 
 func (s *SessionManager) OnPartyDeleted(ctx context.Context, request *sessionmanager.PartyDeletedRequest) (*emptypb.Empty, error) {
 	log.Println("got message from OnPartyDeleted")
 	log.Println("party session deleted", request.GetSession())
+	if err := s.Hooks.OnPartyDeleted(ctx, request.GetSession()); err != nil {
+		log.Println("party session deletion failed:", err)
+
+		return nil, err
+	}
 	return &emptypb.Empty{}, nil
 }
@@ -0,0 +1,30 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package state
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type storeKey struct{}
+
+// NewContext returns a context carrying the store, for the hooks and action handlers to look up related sessions.
+func NewContext(ctx context.Context, store *Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// FromContext returns the store carried by the context.
+func FromContext(ctx context.Context) (*Store, bool) {
+	store, ok := ctx.Value(storeKey{}).(*Store)
+
+	return store, ok
+}
+
+// UnaryServerInterceptor makes the store available to every call through FromContext.
+func (s *Store) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(NewContext(ctx, s), req)
+}
@@ -0,0 +1,366 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package state
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+// tombstoneTTL is how long deleted session IDs are remembered to ignore late updates.
+const tombstoneTTL = 10 * time.Minute
+
+var (
+	liveGameSessionsDesc = prometheus.NewDesc("session_manager_live_game_sessions",
+		"Number of live game sessions known by the plugin", nil, nil)
+	livePartiesDesc = prometheus.NewDesc("session_manager_live_parties",
+		"Number of live party sessions known by the plugin", nil, nil)
+)
+
+// Store is an in-memory view of the live game sessions and parties, fed by the SessionManager callbacks.
+// Snapshots are only replaced by snapshots of the same or a higher BaseSession version.
+// The returned sessions are copies and can be modified freely.
+type Store struct {
+	mu         sync.RWMutex
+	sessions   map[string]*sessionmanager.GameSession
+	parties    map[string]*sessionmanager.PartySession
+	tombstones map[string]time.Time
+	// burials holds the buried IDs in deletion order, to expire the tombstones from the oldest
+	burials []tombstone
+
+	// sessionOfUser and partyOfUser index the joined members
+	sessionOfUser map[string]string
+	partyOfUser   map[string]string
+	// sessionOfParty indexes the parties placed in the game session teams
+	sessionOfParty map[string]string
+}
+
+func NewStore() *Store {
+	return &Store{
+		sessions:       map[string]*sessionmanager.GameSession{},
+		parties:        map[string]*sessionmanager.PartySession{},
+		tombstones:     map[string]time.Time{},
+		sessionOfUser:  map[string]string{},
+		partyOfUser:    map[string]string{},
+		sessionOfParty: map[string]string{},
+	}
+}
+
+// PutSession stores a game session snapshot. It returns false when the snapshot is stale and was ignored.
+func (s *Store) PutSession(session *sessionmanager.GameSession) bool {
+	id := session.GetSession().GetId()
+	if id == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, deleted := s.tombstones[id]; deleted {
+		return false
+	}
+	if current, found := s.sessions[id]; found {
+		if current.GetSession().GetVersion() > session.GetSession().GetVersion() {
+			return false
+		}
+		unindex(s.sessionOfUser, id, current.GetSession())
+		unindexParties(s.sessionOfParty, id, current)
+	}
+
+	session = proto.Clone(session).(*sessionmanager.GameSession)
+	s.sessions[id] = session
+	index(s.sessionOfUser, id, session.GetSession())
+	indexParties(s.sessionOfParty, id, session)
+
+	return true
+}
+
+// DeleteSession removes a game session.
+func (s *Store) DeleteSession(session *sessionmanager.GameSession) {
+	id := session.GetSession().GetId()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, found := s.sessions[id]; found {
+		unindex(s.sessionOfUser, id, current.GetSession())
+		unindexParties(s.sessionOfParty, id, current)
+		delete(s.sessions, id)
+	}
+	s.bury(id)
+}
+
+// PutParty stores a party session snapshot. It returns false when the snapshot is stale and was ignored.
+func (s *Store) PutParty(party *sessionmanager.PartySession) bool {
+	id := party.GetSession().GetId()
+	if id == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, deleted := s.tombstones[id]; deleted {
+		return false
+	}
+	if current, found := s.parties[id]; found {
+		if current.GetSession().GetVersion() > party.GetSession().GetVersion() {
+			return false
+		}
+		unindex(s.partyOfUser, id, current.GetSession())
+	}
+
+	party = proto.Clone(party).(*sessionmanager.PartySession)
+	s.parties[id] = party
+	index(s.partyOfUser, id, party.GetSession())
+
+	return true
+}
+
+// DeleteParty removes a party session.
+func (s *Store) DeleteParty(party *sessionmanager.PartySession) {
+	id := party.GetSession().GetId()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, found := s.parties[id]; found {
+		unindex(s.partyOfUser, id, current.GetSession())
+		delete(s.parties, id)
+	}
+	s.bury(id)
+}
+
+// Session returns the live game session of the ID.
+func (s *Store) Session(id string) (*sessionmanager.GameSession, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, found := s.sessions[id]
+	if !found {
+		return nil, false
+	}
+
+	return proto.Clone(session).(*sessionmanager.GameSession), true
+}
+
+// Party returns the live party session of the ID.
+func (s *Store) Party(id string) (*sessionmanager.PartySession, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	party, found := s.parties[id]
+	if !found {
+		return nil, false
+	}
+
+	return proto.Clone(party).(*sessionmanager.PartySession), true
+}
+
+// SessionOfUser returns the live game session the user has joined.
+func (s *Store) SessionOfUser(userID string) (*sessionmanager.GameSession, bool) {
+	s.mu.RLock()
+	id, found := s.sessionOfUser[userID]
+	s.mu.RUnlock()
+	if !found {
+		return nil, false
+	}
+
+	return s.Session(id)
+}
+
+// PartyOfUser returns the live party session the user has joined.
+func (s *Store) PartyOfUser(userID string) (*sessionmanager.PartySession, bool) {
+	s.mu.RLock()
+	id, found := s.partyOfUser[userID]
+	s.mu.RUnlock()
+	if !found {
+		return nil, false
+	}
+
+	return s.Party(id)
+}
+
+// SessionOfParty returns the live game session whose teams hold the party.
+func (s *Store) SessionOfParty(partyID string) (*sessionmanager.GameSession, bool) {
+	s.mu.RLock()
+	id, found := s.sessionOfParty[partyID]
+	s.mu.RUnlock()
+	if !found {
+		return nil, false
+	}
+
+	return s.Session(id)
+}
+
+// PartiesOf returns the live parties placed in the teams of a game session, in team order. The session doesn't
+// need to be stored, so the parties can be read while the session is being created.
+func (s *Store) PartiesOf(session *sessionmanager.GameSession) []*sessionmanager.PartySession {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var parties []*sessionmanager.PartySession
+	for _, id := range PartyIDs(session) {
+		if party, found := s.parties[id]; found {
+			parties = append(parties, proto.Clone(party).(*sessionmanager.PartySession))
+		}
+	}
+
+	return parties
+}
+
+// Counts returns the number of live game sessions and parties.
+func (s *Store) Counts() (int, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.sessions), len(s.parties)
+}
+
+// Describe implements prometheus.Collector.
+func (s *Store) Describe(ch chan<- *prometheus.Desc) {
+	ch <- liveGameSessionsDesc
+	ch <- livePartiesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (s *Store) Collect(ch chan<- prometheus.Metric) {
+	sessions, parties := s.Counts()
+	ch <- prometheus.MustNewConstMetric(liveGameSessionsDesc, prometheus.GaugeValue, float64(sessions))
+	ch <- prometheus.MustNewConstMetric(livePartiesDesc, prometheus.GaugeValue, float64(parties))
+}
+
+type tombstone struct {
+	id        string
+	deletedAt time.Time
+}
+
+// bury remembers a deleted ID, and forgets the expired ones. It must be called with the lock held.
+func (s *Store) bury(id string) {
+	now := time.Now()
+	expired := 0
+	for _, burial := range s.burials {
+		if now.Sub(burial.deletedAt) <= tombstoneTTL {
+			break
+		}
+		// an ID buried again has a later tombstone further in the queue
+		if s.tombstones[burial.id].Equal(burial.deletedAt) {
+			delete(s.tombstones, burial.id)
+		}
+		expired++
+	}
+	s.burials = s.burials[expired:]
+	if id != "" {
+		s.tombstones[id] = now
+		s.burials = append(s.burials, tombstone{id: id, deletedAt: now})
+	}
+}
+
+func index(byUser map[string]string, id string, session *sessionmanager.BaseSession) {
+	for _, member := range session.GetMembers() {
+		if joined(member) {
+			byUser[member.GetId()] = id
+		}
+	}
+}
+
+func unindex(byUser map[string]string, id string, session *sessionmanager.BaseSession) {
+	for _, member := range session.GetMembers() {
+		if byUser[member.GetId()] == id {
+			delete(byUser, member.GetId())
+		}
+	}
+}
+
+func indexParties(byParty map[string]string, id string, session *sessionmanager.GameSession) {
+	for _, partyID := range PartyIDs(session) {
+		byParty[partyID] = id
+	}
+}
+
+func unindexParties(byParty map[string]string, id string, session *sessionmanager.GameSession) {
+	for _, partyID := range PartyIDs(session) {
+		if byParty[partyID] == id {
+			delete(byParty, partyID)
+		}
+	}
+}
+
+// PartyIDs returns the IDs of the parties referenced by the team members of a game session, without duplicates.
+func PartyIDs(session *sessionmanager.GameSession) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, team := range session.GetTeams() {
+		for _, member := range team.GetPartyMembers() {
+			if id := member.GetPartyId(); id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+func joined(member *sessionmanager.User) bool {
+	status := member.GetStatusV2()
+	if status == "" {
+		status = member.GetStatus()
+	}
+
+	return status == "JOINED" || status == "CONNECTED"
+}
+
+func (s *Store) Name() string {
+	return "state"
+}
+
+func (s *Store) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	s.PutSession(session)
+
+	return session, nil
+}
+
+func (s *Store) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	if !s.PutSession(request.GetSessionNew()) {
+		slog.Default().Info("ignored stale game session version", "session", request.GetSessionNew().GetSession().GetId(),
+			"version", request.GetSessionNew().GetSession().GetVersion())
+	}
+
+	return nil
+}
+
+func (s *Store) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	s.DeleteSession(session)
+
+	return nil
+}
+
+func (s *Store) OnPartyCreated(ctx context.Context, party *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	s.PutParty(party)
+
+	return party, nil
+}
+
+func (s *Store) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	if !s.PutParty(request.GetSessionNew()) {
+		slog.Default().Info("ignored stale party session version", "party", request.GetSessionNew().GetSession().GetId(),
+			"version", request.GetSessionNew().GetSession().GetVersion())
+	}
+
+	return nil
+}
+
+func (s *Store) OnPartyDeleted(ctx context.Context, party *sessionmanager.PartySession) error {
+	s.DeleteParty(party)
+
+	return nil
+}
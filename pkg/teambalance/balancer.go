@@ -0,0 +1,238 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package teambalance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Config specifies where the Balancer reads member skills from.
+type Config struct {
+	// SkillAttribute is the dotted path of the session attribute holding an object of user ID to skill,
+	// e.g. "skills" for {"skills": {"user-a": 1200}}.
+	SkillAttribute string `yaml:"skillAttribute" json:"skillAttribute"`
+	// RatingsFile is a YAML or JSON file of user ID to skill, used for users missing from the session attribute.
+	RatingsFile string `yaml:"ratingsFile" json:"ratingsFile"`
+	// DefaultSkill is used for users with no known skill.
+	DefaultSkill float64 `yaml:"defaultSkill" json:"defaultSkill"`
+}
+
+// Balancer redistributes the members of game session teams to minimize the skill difference between teams.
+// Members of the same party are always kept in the same team, and team sizes are preserved.
+type Balancer struct {
+	skillAttribute string
+	ratings        map[string]float64
+	defaultSkill   float64
+}
+
+// group is a set of users that must be placed in the same team.
+type group struct {
+	partyID string
+	userIDs []string
+	skill   float64
+}
+
+func NewBalancer(config Config) (*Balancer, error) {
+	ratings := map[string]float64{}
+	if config.RatingsFile != "" {
+		content, err := os.ReadFile(config.RatingsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = yaml.Unmarshal(content, &ratings); err != nil {
+			return nil, fmt.Errorf("failed to parse ratings file %s: %w", config.RatingsFile, err)
+		}
+	}
+
+	return &Balancer{
+		skillAttribute: config.SkillAttribute,
+		ratings:        ratings,
+		defaultSkill:   config.DefaultSkill,
+	}, nil
+}
+
+// Balance rebalances the teams of the game session in place. It returns true when the teams were changed.
+// The teams are left untouched when they can't be improved without splitting a party.
+func (b *Balancer) Balance(session *sessionmanager.GameSession) bool {
+	teams := session.GetTeams()
+	if len(teams) < 2 {
+		return false
+	}
+
+	skills := b.sessionSkills(session.GetSession())
+	groups, capacities := b.groups(teams, skills)
+
+	// place the largest and strongest groups first, they are the hardest to fit
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].userIDs) != len(groups[j].userIDs) {
+			return len(groups[i].userIDs) > len(groups[j].userIDs)
+		}
+
+		return groups[i].skill > groups[j].skill
+	})
+
+	assigned := make([][]group, len(teams))
+	totals := make([]float64, len(teams))
+	for _, g := range groups {
+		best := -1
+		for i := range teams {
+			if capacities[i] < len(g.userIDs) {
+				continue
+			}
+			if best < 0 || totals[i] < totals[best] {
+				best = i
+			}
+		}
+		if best < 0 {
+			return false
+		}
+
+		assigned[best] = append(assigned[best], g)
+		totals[best] += g.skill
+		capacities[best] -= len(g.userIDs)
+	}
+
+	if spread(totals) >= spread(b.teamSkills(teams, skills)) {
+		return false
+	}
+
+	for i, team := range teams {
+		team.UserIds = nil
+		team.PartyMembers = nil
+		for _, g := range assigned[i] {
+			team.UserIds = append(team.UserIds, g.userIDs...)
+			if g.partyID != "" {
+				team.PartyMembers = append(team.PartyMembers, &sessionmanager.PartyMember{
+					PartyId: g.partyID,
+					UserIds: g.userIDs,
+				})
+			}
+		}
+	}
+
+	return true
+}
+
+// groups splits the teams into parties and solo players, and returns them along with the size of each team.
+func (b *Balancer) groups(teams []*sessionmanager.Team, skills map[string]float64) ([]group, []int) {
+	var groups []group
+	capacities := make([]int, len(teams))
+	for i, team := range teams {
+		inParty := map[string]bool{}
+		for _, partyMember := range team.GetPartyMembers() {
+			g := group{partyID: partyMember.GetPartyId()}
+			for _, userID := range partyMember.GetUserIds() {
+				if inParty[userID] {
+					continue
+				}
+				inParty[userID] = true
+				g.userIDs = append(g.userIDs, userID)
+				g.skill += b.skill(userID, skills)
+			}
+			if len(g.userIDs) > 0 {
+				groups = append(groups, g)
+				capacities[i] += len(g.userIDs)
+			}
+		}
+
+		for _, userID := range team.GetUserIds() {
+			if inParty[userID] {
+				continue
+			}
+			groups = append(groups, group{userIDs: []string{userID}, skill: b.skill(userID, skills)})
+			capacities[i]++
+		}
+	}
+
+	return groups, capacities
+}
+
+func (b *Balancer) teamSkills(teams []*sessionmanager.Team, skills map[string]float64) []float64 {
+	totals := make([]float64, len(teams))
+	for i, team := range teams {
+		counted := map[string]bool{}
+		for _, userID := range team.GetUserIds() {
+			counted[userID] = true
+			totals[i] += b.skill(userID, skills)
+		}
+		for _, partyMember := range team.GetPartyMembers() {
+			for _, userID := range partyMember.GetUserIds() {
+				if !counted[userID] {
+					counted[userID] = true
+					totals[i] += b.skill(userID, skills)
+				}
+			}
+		}
+	}
+
+	return totals
+}
+
+// sessionSkills reads the skills provided in the session attributes.
+func (b *Balancer) sessionSkills(session *sessionmanager.BaseSession) map[string]float64 {
+	skills := map[string]float64{}
+	if b.skillAttribute == "" {
+		return skills
+	}
+
+	value, err := utils.GetStruct(session.GetAttributes(), b.skillAttribute)
+	if err != nil {
+		if !errors.Is(err, utils.ErrNotFound) {
+			slog.Default().Warn("ignored invalid skill attribute", "session", session.GetId(), "error", err)
+		}
+
+		return skills
+	}
+
+	for userID, skill := range value.GetFields() {
+		skills[userID] = skill.GetNumberValue()
+	}
+
+	return skills
+}
+
+func (b *Balancer) skill(userID string, skills map[string]float64) float64 {
+	if skill, found := skills[userID]; found {
+		return skill
+	}
+	if skill, found := b.ratings[userID]; found {
+		return skill
+	}
+
+	return b.defaultSkill
+}
+
+func spread(totals []float64) float64 {
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, total := range totals {
+		lowest = math.Min(lowest, total)
+		highest = math.Max(highest, total)
+	}
+
+	return highest - lowest
+}
+
+func (b *Balancer) Name() string {
+	return "team-balance"
+}
+
+func (b *Balancer) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	if b.Balance(session) {
+		slog.Default().Info("rebalanced teams", "session", session.GetSession().GetId(), "teams", session.GetTeams())
+	}
+
+	return session, nil
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrNotFound is returned when no value is set at a path.
+var ErrNotFound = errors.New("attribute not found")
+
+// TypeError is returned when the value at a path, or one of its parents, doesn't have the expected type.
+type TypeError struct {
+	Path string
+	Want string
+	Got  string
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("attribute %s is a %s, not a %s", e.Path, e.Got, e.Want)
+}
+
+// The attribute functions read and write the values of a structpb.Struct, such as the session attributes, by
+// dotted path, e.g. "match.settings.map". A nil struct reads as an empty one. Keys containing dots can't be reached.
+
+// EnsureStruct returns the struct of a field, creating the struct and its fields when they are nil.
+func EnsureStruct(field **structpb.Struct) *structpb.Struct {
+	if *field == nil {
+		*field = &structpb.Struct{}
+	}
+	if (*field).Fields == nil {
+		(*field).Fields = map[string]*structpb.Value{}
+	}
+
+	return *field
+}
+
+// CopyStruct returns a deep copy of a struct, an empty struct when it is nil.
+func CopyStruct(s *structpb.Struct) *structpb.Struct {
+	if s == nil {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+
+	return proto.Clone(s).(*structpb.Struct)
+}
+
+// GetValue returns the value at the path. The value is not copied.
+func GetValue(s *structpb.Struct, path string) (*structpb.Value, error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := s
+	for i, key := range keys {
+		value, found := current.GetFields()[key]
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		if i == len(keys)-1 {
+			return value, nil
+		}
+
+		if current = value.GetStructValue(); current == nil {
+			return nil, &TypeError{Path: strings.Join(keys[:i+1], "."), Want: "struct", Got: kindOf(value)}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+}
+
+// GetString returns the string at the path.
+func GetString(s *structpb.Struct, path string) (string, error) {
+	value, err := getKind(s, path, "string")
+	if err != nil {
+		return "", err
+	}
+
+	return value.GetStringValue(), nil
+}
+
+// GetNumber returns the number at the path.
+func GetNumber(s *structpb.Struct, path string) (float64, error) {
+	value, err := getKind(s, path, "number")
+	if err != nil {
+		return 0, err
+	}
+
+	return value.GetNumberValue(), nil
+}
+
+// GetBool returns the bool at the path.
+func GetBool(s *structpb.Struct, path string) (bool, error) {
+	value, err := getKind(s, path, "bool")
+	if err != nil {
+		return false, err
+	}
+
+	return value.GetBoolValue(), nil
+}
+
+// GetList returns a copy of the list at the path.
+func GetList(s *structpb.Struct, path string) (*structpb.ListValue, error) {
+	value, err := getKind(s, path, "list")
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Clone(value.GetListValue()).(*structpb.ListValue), nil
+}
+
+// GetStruct returns a copy of the nested struct at the path.
+func GetStruct(s *structpb.Struct, path string) (*structpb.Struct, error) {
+	value, err := getKind(s, path, "struct")
+	if err != nil {
+		return nil, err
+	}
+
+	return CopyStruct(value.GetStructValue()), nil
+}
+
+// SetValue sets the value at the path, creating the missing intermediate structs. The value is either a
+// *structpb.Value, a *structpb.Struct, a *structpb.ListValue, which are copied, or any value accepted by
+// structpb.NewValue. It fails with a TypeError when a parent exists and is not a struct.
+func SetValue(s *structpb.Struct, path string, value interface{}) error {
+	if s == nil {
+		return errors.New("can't set an attribute of a nil struct")
+	}
+	keys, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	converted, err := toValue(value)
+	if err != nil {
+		return fmt.Errorf("attribute %s: %w", path, err)
+	}
+
+	current := s
+	for i, key := range keys[:len(keys)-1] {
+		if current.Fields == nil {
+			current.Fields = map[string]*structpb.Value{}
+		}
+		child, found := current.Fields[key]
+		if !found {
+			child = structpb.NewStructValue(&structpb.Struct{})
+			current.Fields[key] = child
+		}
+		if current = child.GetStructValue(); current == nil {
+			return &TypeError{Path: strings.Join(keys[:i+1], "."), Want: "struct", Got: kindOf(child)}
+		}
+	}
+	if current.Fields == nil {
+		current.Fields = map[string]*structpb.Value{}
+	}
+	current.Fields[keys[len(keys)-1]] = converted
+
+	return nil
+}
+
+// DeleteValue removes the value at the path. It returns false when no value was set.
+func DeleteValue(s *structpb.Struct, path string) bool {
+	keys, err := splitPath(path)
+	if err != nil {
+		return false
+	}
+
+	current := s
+	for _, key := range keys[:len(keys)-1] {
+		if current = current.GetFields()[key].GetStructValue(); current == nil {
+			return false
+		}
+	}
+	if _, found := current.GetFields()[keys[len(keys)-1]]; !found {
+		return false
+	}
+	delete(current.Fields, keys[len(keys)-1])
+
+	return true
+}
+
+func getKind(s *structpb.Struct, path string, want string) (*structpb.Value, error) {
+	value, err := GetValue(s, path)
+	if err != nil {
+		return nil, err
+	}
+	if got := kindOf(value); got != want {
+		return nil, &TypeError{Path: path, Want: want, Got: got}
+	}
+
+	return value, nil
+}
+
+func splitPath(path string) ([]string, error) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("invalid attribute path %q", path)
+		}
+	}
+
+	return keys, nil
+}
+
+func toValue(value interface{}) (*structpb.Value, error) {
+	switch v := value.(type) {
+	case *structpb.Value:
+		return proto.Clone(v).(*structpb.Value), nil
+	case *structpb.Struct:
+		return structpb.NewStructValue(CopyStruct(v)), nil
+	case *structpb.ListValue:
+		return structpb.NewListValue(proto.Clone(v).(*structpb.ListValue)), nil
+	default:
+		return structpb.NewValue(value)
+	}
+}
+
+func kindOf(value *structpb.Value) string {
+	switch value.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return "string"
+	case *structpb.Value_NumberValue:
+		return "number"
+	case *structpb.Value_BoolValue:
+		return "bool"
+	case *structpb.Value_ListValue:
+		return "list"
+	case *structpb.Value_StructValue:
+		return "struct"
+	default:
+		return "null"
+	}
+}
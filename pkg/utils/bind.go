@@ -0,0 +1,421 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrRequired is returned when a required attribute is missing or null.
+var ErrRequired = errors.New("required attribute missing")
+
+var (
+	valueType  = reflect.TypeOf((*structpb.Value)(nil))
+	structType = reflect.TypeOf((*structpb.Struct)(nil))
+)
+
+// DecodeStruct and EncodeStruct bind the values of a structpb.Struct, such as the session attributes or storages,
+// to the fields of a Go struct tagged with `attr`, e.g.
+//
+//	type Match struct {
+//		Region   string   `attr:"region,required"`
+//		Map      string   `attr:"settings.map,default=dust"`
+//		Mutators []string `attr:"mutators,omitempty"`
+//	}
+//
+// The tag name is a dotted path relative to the enclosing struct. Its options are "required", failing the decoding
+// with ErrRequired when the value is missing or null, "default=<value>", decoded when the value is missing or null,
+// and "omitempty", not encoding zero values. Fields without a tag, or tagged "-", are ignored. Supported field types
+// are strings, bools, numbers, nested structs, pointers, slices, maps of string keys, interface{} and the structpb
+// Value and Struct pointers, which are copied.
+
+// DecodeStruct decodes s into the tagged fields of the struct v points to. Fields of missing values without a
+// default are left unchanged. Values of the wrong type fail the decoding with a TypeError.
+func DecodeStruct(s *structpb.Struct, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can't decode attributes into %T, not a pointer to a struct", v)
+	}
+
+	return decodeFields(s, rv.Elem(), "")
+}
+
+// EncodeStruct writes the tagged fields of the struct v, or of the struct v points to, into s, creating the missing
+// intermediate structs. The other values of s are kept, so decoding, modifying and encoding a struct only changes
+// the bound values.
+func EncodeStruct(v interface{}, s *structpb.Struct) error {
+	if s == nil {
+		return errors.New("can't encode attributes into a nil struct")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("can't encode attributes from %T, not a struct", v)
+	}
+
+	return encodeFields(rv, s, "")
+}
+
+type attrTag struct {
+	path         string
+	required     bool
+	omitEmpty    bool
+	defaultValue *string
+}
+
+// parseTag returns false when the field is not bound.
+func parseTag(field reflect.StructField) (attrTag, bool, error) {
+	tag, found := field.Tag.Lookup("attr")
+	if !found || tag == "-" || !field.IsExported() {
+		return attrTag{}, false, nil
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	parsed := attrTag{path: name}
+	if parsed.path == "" {
+		return attrTag{}, false, fmt.Errorf("field %s: missing attribute name", field.Name)
+	}
+	for options != "" {
+		var option string
+		// the default value is the last option since it can contain commas
+		if strings.HasPrefix(options, "default=") {
+			option, options = options, ""
+		} else {
+			option, options, _ = strings.Cut(options, ",")
+		}
+
+		switch {
+		case option == "required":
+			parsed.required = true
+		case option == "omitempty":
+			parsed.omitEmpty = true
+		case strings.HasPrefix(option, "default="):
+			defaultValue := strings.TrimPrefix(option, "default=")
+			parsed.defaultValue = &defaultValue
+		default:
+			return attrTag{}, false, fmt.Errorf("field %s: unknown attribute option %q", field.Name, option)
+		}
+	}
+
+	return parsed, true, nil
+}
+
+func decodeFields(s *structpb.Struct, rv reflect.Value, prefix string) error {
+	for i := 0; i < rv.NumField(); i++ {
+		tag, bound, err := parseTag(rv.Type().Field(i))
+		if err != nil {
+			return err
+		}
+		if !bound {
+			continue
+		}
+		path := joinPath(prefix, tag.path)
+
+		value, err := GetValue(s, tag.path)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return withPrefix(err, prefix)
+		}
+		if value == nil || kindOf(value) == "null" {
+			switch {
+			case tag.defaultValue != nil:
+				if err = decodeDefault(*tag.defaultValue, rv.Field(i), path); err != nil {
+					return err
+				}
+			case tag.required:
+				return fmt.Errorf("%w: %s", ErrRequired, path)
+			}
+
+			continue
+		}
+
+		if err = decodeValue(value, rv.Field(i), path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeValue(value *structpb.Value, rv reflect.Value, path string) error {
+	switch rv.Type() {
+	case valueType:
+		rv.Set(reflect.ValueOf(proto.Clone(value).(*structpb.Value)))
+
+		return nil
+	case structType:
+		if kindOf(value) != "struct" {
+			return &TypeError{Path: path, Want: "struct", Got: kindOf(value)}
+		}
+		rv.Set(reflect.ValueOf(CopyStruct(value.GetStructValue())))
+
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if kindOf(value) == "null" {
+			rv.Set(reflect.Zero(rv.Type()))
+
+			return nil
+		}
+		elem := reflect.New(rv.Type().Elem())
+		if err := decodeValue(value, elem.Elem(), path); err != nil {
+			return err
+		}
+		rv.Set(elem)
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return fmt.Errorf("attribute %s: unsupported type %s", path, rv.Type())
+		}
+		if decoded := value.AsInterface(); decoded != nil {
+			rv.Set(reflect.ValueOf(decoded))
+		} else {
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+	case reflect.String:
+		if kindOf(value) != "string" {
+			return &TypeError{Path: path, Want: "string", Got: kindOf(value)}
+		}
+		rv.SetString(value.GetStringValue())
+	case reflect.Bool:
+		if kindOf(value) != "bool" {
+			return &TypeError{Path: path, Want: "bool", Got: kindOf(value)}
+		}
+		rv.SetBool(value.GetBoolValue())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if kindOf(value) != "number" {
+			return &TypeError{Path: path, Want: "number", Got: kindOf(value)}
+		}
+		number := value.GetNumberValue()
+		if number != math.Trunc(number) || number < -(1<<63) || number >= 1<<63 || rv.OverflowInt(int64(number)) {
+			return fmt.Errorf("attribute %s: %v is not a valid %s", path, number, rv.Type())
+		}
+		rv.SetInt(int64(number))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if kindOf(value) != "number" {
+			return &TypeError{Path: path, Want: "number", Got: kindOf(value)}
+		}
+		number := value.GetNumberValue()
+		if number != math.Trunc(number) || number < 0 || number >= 1<<64 || rv.OverflowUint(uint64(number)) {
+			return fmt.Errorf("attribute %s: %v is not a valid %s", path, number, rv.Type())
+		}
+		rv.SetUint(uint64(number))
+	case reflect.Float32, reflect.Float64:
+		if kindOf(value) != "number" {
+			return &TypeError{Path: path, Want: "number", Got: kindOf(value)}
+		}
+		rv.SetFloat(value.GetNumberValue())
+	case reflect.Struct:
+		if kindOf(value) != "struct" {
+			return &TypeError{Path: path, Want: "struct", Got: kindOf(value)}
+		}
+
+		return decodeFields(value.GetStructValue(), rv, path)
+	case reflect.Slice:
+		if kindOf(value) != "list" {
+			return &TypeError{Path: path, Want: "list", Got: kindOf(value)}
+		}
+		items := value.GetListValue().GetValues()
+		slice := reflect.MakeSlice(rv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, slice.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("attribute %s: unsupported type %s", path, rv.Type())
+		}
+		if kindOf(value) != "struct" {
+			return &TypeError{Path: path, Want: "struct", Got: kindOf(value)}
+		}
+		fields := value.GetStructValue().GetFields()
+		m := reflect.MakeMapWithSize(rv.Type(), len(fields))
+		for key, item := range fields {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := decodeValue(item, elem, joinPath(path, key)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), elem)
+		}
+		rv.Set(m)
+	default:
+		return fmt.Errorf("attribute %s: unsupported type %s", path, rv.Type())
+	}
+
+	return nil
+}
+
+// decodeDefault decodes the default value of a tag, which is a string for string fields and is parsed otherwise.
+func decodeDefault(defaultValue string, rv reflect.Value, path string) error {
+	target := rv
+	if target.Kind() == reflect.Pointer {
+		target = reflect.New(rv.Type().Elem()).Elem()
+	}
+
+	var value *structpb.Value
+	switch target.Kind() {
+	case reflect.String:
+		value = structpb.NewStringValue(defaultValue)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(defaultValue)
+		if err != nil {
+			return fmt.Errorf("attribute %s: invalid default %q: %w", path, defaultValue, err)
+		}
+		value = structpb.NewBoolValue(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(defaultValue, 64)
+		if err != nil {
+			return fmt.Errorf("attribute %s: invalid default %q: %w", path, defaultValue, err)
+		}
+		value = structpb.NewNumberValue(parsed)
+	default:
+		return fmt.Errorf("attribute %s: defaults are not supported for %s", path, rv.Type())
+	}
+
+	return decodeValue(value, rv, path)
+}
+
+func encodeFields(rv reflect.Value, s *structpb.Struct, prefix string) error {
+	for i := 0; i < rv.NumField(); i++ {
+		tag, bound, err := parseTag(rv.Type().Field(i))
+		if err != nil {
+			return err
+		}
+		if !bound || (tag.omitEmpty && rv.Field(i).IsZero()) {
+			continue
+		}
+
+		// nested structs are merged into the existing ones, to keep their unbound values
+		existing, _ := GetValue(s, tag.path)
+		value, err := encodeValue(rv.Field(i), existing, joinPath(prefix, tag.path))
+		if err != nil {
+			return err
+		}
+		if err = SetValue(s, tag.path, value); err != nil {
+			return withPrefix(err, prefix)
+		}
+	}
+
+	return nil
+}
+
+func encodeValue(rv reflect.Value, existing *structpb.Value, path string) (*structpb.Value, error) {
+	switch rv.Type() {
+	case valueType:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+
+		return proto.Clone(rv.Interface().(*structpb.Value)).(*structpb.Value), nil
+	case structType:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+
+		return structpb.NewStructValue(CopyStruct(rv.Interface().(*structpb.Struct))), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+
+		return encodeValue(rv.Elem(), existing, path)
+	case reflect.Interface:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		value, err := structpb.NewValue(rv.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", path, err)
+		}
+
+		return value, nil
+	case reflect.String:
+		return structpb.NewStringValue(rv.String()), nil
+	case reflect.Bool:
+		return structpb.NewBoolValue(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return structpb.NewNumberValue(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return structpb.NewNumberValue(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return structpb.NewNumberValue(rv.Float()), nil
+	case reflect.Struct:
+		s := CopyStruct(existing.GetStructValue())
+		if err := encodeFields(rv, s, path); err != nil {
+			return nil, err
+		}
+
+		return structpb.NewStructValue(s), nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		list := &structpb.ListValue{Values: make([]*structpb.Value, rv.Len())}
+		for i := range list.Values {
+			item, err := encodeValue(rv.Index(i), nil, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			list.Values[i] = item
+		}
+
+		return structpb.NewListValue(list), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("attribute %s: unsupported type %s", path, rv.Type())
+		}
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		s := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		for iter := rv.MapRange(); iter.Next(); {
+			key := iter.Key().String()
+			item, err := encodeValue(iter.Value(), nil, joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			s.Fields[key] = item
+		}
+
+		return structpb.NewStructValue(s), nil
+	default:
+		return nil, fmt.Errorf("attribute %s: unsupported type %s", path, rv.Type())
+	}
+}
+
+func joinPath(prefix string, path string) string {
+	if prefix == "" {
+		return path
+	}
+
+	return prefix + "." + path
+}
+
+// withPrefix makes the path of a TypeError relative to the decoded or encoded root struct.
+func withPrefix(err error, prefix string) error {
+	var typeErr *TypeError
+	if prefix != "" && errors.As(err, &typeErr) {
+		return &TypeError{Path: joinPath(prefix, typeErr.Path), Want: typeErr.Want, Got: typeErr.Got}
+	}
+
+	return err
+}
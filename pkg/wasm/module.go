@@ -0,0 +1,353 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	moduleSuffix = ".wasm"
+
+	// hostModule is the name of the module providing the host functions.
+	hostModule = "session_manager"
+
+	allocFunction = "alloc"
+)
+
+// Functions a module can export, each invoked by the callback of the same name.
+const (
+	onSessionCreated = "on_session_created"
+	onSessionUpdated = "on_session_updated"
+	onSessionDeleted = "on_session_deleted"
+	onPartyCreated   = "on_party_created"
+	onPartyUpdated   = "on_party_updated"
+	onPartyDeleted   = "on_party_deleted"
+)
+
+var callbackFunctions = []string{onSessionCreated, onSessionUpdated, onSessionDeleted, onPartyCreated, onPartyUpdated, onPartyDeleted}
+
+// Config specifies where modules are loaded from and their limits.
+type Config struct {
+	Dir string
+	// MemoryLimitPages bounds the linear memory of a module instance, in 64 KiB pages. 0 keeps the wazero default.
+	MemoryLimitPages uint32
+	// Timeout bounds the duration of a single call. 0 means unlimited.
+	Timeout time.Duration
+}
+
+// Module is a WebAssembly module exporting some of the on_session_created, on_session_updated, on_session_deleted,
+// on_party_created, on_party_updated and on_party_deleted functions, along with its memory and an alloc function.
+//
+// Each callback function has the (ptr i32, len i32) -> i64 signature. The host calls alloc(len i32) -> i32 to get a
+// buffer, writes the protobuf encoded request into it, e.g. a SessionCreatedRequest, and calls the function with it.
+// Created functions return the location of the protobuf encoded GameSession or PartySession as ptr << 32 | len,
+// or 0 to leave the session unchanged. The result of the other functions is ignored.
+//
+// Modules can import the session_manager.reject(ptr i32, len i32) function to refuse a created session with the
+// message, and session_manager.log(ptr i32, len i32) to write to the app log. WASI is available without any
+// file system, environment or clock access beyond the defaults. Every call runs in a new instance of the module, so
+// no state is kept between calls.
+type Module struct {
+	name     string
+	config   Config
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	exports  map[string]bool
+	log      *slog.Logger
+}
+
+// callState holds what the host functions collected during a call.
+type callState struct {
+	rejection *string
+}
+
+type callStateKey struct{}
+
+// LoadDir compiles every .wasm file of the directory, in file name order.
+// The first file failing to compile or missing required exports is reported.
+func LoadDir(ctx context.Context, config Config) ([]*Module, error) {
+	entries, err := os.ReadDir(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), moduleSuffix) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	modules := make([]*Module, 0, len(names))
+	for _, name := range names {
+		binary, err := os.ReadFile(filepath.Join(config.Dir, name))
+		if err != nil {
+			closeAll(ctx, modules)
+
+			return nil, err
+		}
+		module, err := Load(ctx, config, strings.TrimSuffix(name, moduleSuffix), binary)
+		if err != nil {
+			closeAll(ctx, modules)
+
+			return nil, err
+		}
+		modules = append(modules, module)
+	}
+
+	return modules, nil
+}
+
+// Load compiles a module, checking the signatures of its exported functions.
+func Load(ctx context.Context, config Config, name string, binary []byte) (*Module, error) {
+	runtimeConfig := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if config.MemoryLimitPages > 0 {
+		runtimeConfig = runtimeConfig.WithMemoryLimitPages(config.MemoryLimitPages)
+	}
+
+	module := &Module{
+		name:    name,
+		config:  config,
+		runtime: wazero.NewRuntimeWithConfig(ctx, runtimeConfig),
+		exports: map[string]bool{},
+		log:     slog.Default().With("module", name),
+	}
+
+	err := module.load(ctx, binary)
+	if err != nil {
+		_ = module.runtime.Close(ctx)
+
+		return nil, fmt.Errorf("failed to load module %s: %w", name, err)
+	}
+
+	return module, nil
+}
+
+func (m *Module) load(ctx context.Context, binary []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, m.runtime); err != nil {
+		return err
+	}
+	_, err := m.runtime.NewHostModuleBuilder(hostModule).
+		NewFunctionBuilder().WithFunc(m.reject).Export("reject").
+		NewFunctionBuilder().WithFunc(m.print).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.compiled, err = m.runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return err
+	}
+
+	exported := m.compiled.ExportedFunctions()
+	for _, function := range callbackFunctions {
+		definition, found := exported[function]
+		if !found {
+			continue
+		}
+		if !hasSignature(definition, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}) {
+			return fmt.Errorf("%s must have the (i32, i32) -> i64 signature", function)
+		}
+		m.exports[function] = true
+	}
+	if len(m.exports) == 0 {
+		return nil
+	}
+
+	alloc, found := exported[allocFunction]
+	if !found {
+		return fmt.Errorf("missing %s export", allocFunction)
+	}
+	if !hasSignature(alloc, []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}) {
+		return fmt.Errorf("%s must have the (i32) -> i32 signature", allocFunction)
+	}
+	if _, found = m.compiled.ExportedMemories()["memory"]; !found {
+		return fmt.Errorf("missing memory export")
+	}
+
+	return nil
+}
+
+// Functions returns the names of the callback functions the module exports.
+func (m *Module) Functions() []string {
+	var functions []string
+	for _, function := range callbackFunctions {
+		if m.exports[function] {
+			functions = append(functions, function)
+		}
+	}
+
+	return functions
+}
+
+// Close releases the runtime of the module.
+func (m *Module) Close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}
+
+func (m *Module) Name() string {
+	return "wasm:" + m.name
+}
+
+func (m *Module) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	output, err := m.call(ctx, onSessionCreated, &sessionmanager.SessionCreatedRequest{Session: session})
+	if err != nil || output == nil {
+		return session, err
+	}
+
+	result := &sessionmanager.GameSession{}
+	if err = proto.Unmarshal(output, result); err != nil {
+		return nil, fmt.Errorf("%s returned an invalid session: %w", onSessionCreated, err)
+	}
+
+	return result, nil
+}
+
+func (m *Module) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	_, err := m.call(ctx, onSessionUpdated, request)
+
+	return err
+}
+
+func (m *Module) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	_, err := m.call(ctx, onSessionDeleted, &sessionmanager.SessionDeletedRequest{Session: session})
+
+	return err
+}
+
+func (m *Module) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	output, err := m.call(ctx, onPartyCreated, &sessionmanager.PartyCreatedRequest{Session: session})
+	if err != nil || output == nil {
+		return session, err
+	}
+
+	result := &sessionmanager.PartySession{}
+	if err = proto.Unmarshal(output, result); err != nil {
+		return nil, fmt.Errorf("%s returned an invalid session: %w", onPartyCreated, err)
+	}
+
+	return result, nil
+}
+
+func (m *Module) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	_, err := m.call(ctx, onPartyUpdated, request)
+
+	return err
+}
+
+func (m *Module) OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error {
+	_, err := m.call(ctx, onPartyDeleted, &sessionmanager.PartyDeletedRequest{Session: session})
+
+	return err
+}
+
+// call runs the function in a new instance with the encoded request, and returns a copy of the output it points to.
+// It returns nil when the module doesn't export the function or returns no output.
+func (m *Module) call(ctx context.Context, function string, request proto.Message) ([]byte, error) {
+	if !m.exports[function] {
+		return nil, nil
+	}
+
+	input, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.config.Timeout)
+		defer cancel()
+	}
+	state := &callState{}
+	ctx = context.WithValue(ctx, callStateKey{}, state)
+
+	// _initialize sets up reactor modules, e.g. built with TinyGo or -buildmode=c-shared
+	instance, err := m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+	defer instance.Close(context.Background())
+
+	results, err := instance.ExportedFunction(allocFunction).Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", allocFunction, err)
+	}
+	pointer := uint32(results[0])
+	if !instance.Memory().Write(pointer, input) {
+		return nil, fmt.Errorf("%s returned an out of range buffer", allocFunction)
+	}
+
+	results, err = instance.ExportedFunction(function).Call(ctx, uint64(pointer), uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", function, err)
+	}
+	if state.rejection != nil {
+		return nil, status.Error(codes.FailedPrecondition, *state.rejection)
+	}
+
+	outputPointer, outputLength := uint32(results[0]>>32), uint32(results[0])
+	if outputLength == 0 {
+		return nil, nil
+	}
+	output, ok := instance.Memory().Read(outputPointer, outputLength)
+	if !ok {
+		return nil, fmt.Errorf("%s returned an out of range output", function)
+	}
+
+	// the memory is released with the instance
+	return bytes.Clone(output), nil
+}
+
+// reject is the session_manager.reject host function.
+func (m *Module) reject(ctx context.Context, module api.Module, pointer, length uint32) {
+	state, ok := ctx.Value(callStateKey{}).(*callState)
+	if !ok {
+		return
+	}
+	message, ok := module.Memory().Read(pointer, length)
+	if !ok {
+		panic(fmt.Errorf("reject message out of range"))
+	}
+	rejection := string(message)
+	state.rejection = &rejection
+}
+
+// print is the session_manager.log host function.
+func (m *Module) print(ctx context.Context, module api.Module, pointer, length uint32) {
+	message, ok := module.Memory().Read(pointer, length)
+	if !ok {
+		panic(fmt.Errorf("log message out of range"))
+	}
+	m.log.Info(string(message))
+}
+
+func hasSignature(definition api.FunctionDefinition, params []api.ValueType, results []api.ValueType) bool {
+	return bytes.Equal(definition.ParamTypes(), params) && bytes.Equal(definition.ResultTypes(), results)
+}
+
+func closeAll(ctx context.Context, modules []*Module) {
+	for _, module := range modules {
+		_ = module.Close(ctx)
+	}
+}
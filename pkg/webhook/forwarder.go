@@ -0,0 +1,322 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EventSessionCreated = "session.created"
+	EventSessionUpdated = "session.updated"
+	EventSessionDeleted = "session.deleted"
+	EventPartyCreated   = "party.created"
+	EventPartyUpdated   = "party.updated"
+	EventPartyDeleted   = "party.deleted"
+
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventIDHeader   = "X-Webhook-Event-Id"
+)
+
+// Config is the content of a webhook configuration file.
+type Config struct {
+	// QueueSize bounds the number of pending deliveries, new deliveries are dropped when the queue is full.
+	QueueSize int `yaml:"queueSize" json:"queueSize"`
+	// Workers is the number of concurrent deliveries.
+	Workers   int        `yaml:"workers" json:"workers"`
+	Endpoints []Endpoint `yaml:"endpoints" json:"endpoints"`
+}
+
+// Endpoint is an HTTP endpoint receiving events.
+type Endpoint struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url" json:"url"`
+	// Secret is the HMAC-SHA256 signing key. SecretEnv names an environment variable holding it instead. One of them
+	// is required.
+	Secret         string        `yaml:"secret" json:"secret"`
+	SecretEnv      string        `yaml:"secretEnv" json:"secretEnv"`
+	Timeout        time.Duration `yaml:"timeout" json:"timeout"`
+	MaxRetries     int           `yaml:"maxRetries" json:"maxRetries"`
+	InitialBackoff time.Duration `yaml:"initialBackoff" json:"initialBackoff"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff" json:"maxBackoff"`
+	// EventTypes and Namespaces filter the forwarded events, empty means all.
+	EventTypes []string `yaml:"eventTypes" json:"eventTypes"`
+	Namespaces []string `yaml:"namespaces" json:"namespaces"`
+}
+
+// Event is the JSON body posted to the endpoints.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Namespace string          `json:"namespace"`
+	Timestamp time.Time       `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type delivery struct {
+	endpoint *Endpoint
+	eventID  string
+	body     []byte
+}
+
+// Forwarder posts session lifecycle events to webhook endpoints in the background.
+type Forwarder struct {
+	endpoints []Endpoint
+	client    *http.Client
+	queue     chan delivery
+	wg        sync.WaitGroup
+	log       *slog.Logger
+	// stop is closed when Close gives up waiting, to end the retry backoffs
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// LoadFile reads a webhook configuration file.
+func LoadFile(path string) (Config, error) {
+	var config Config
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("failed to parse webhook configuration file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// NewForwarder validates the config and starts the delivery workers.
+func NewForwarder(config Config) (*Forwarder, error) {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+
+	endpoints := make([]Endpoint, len(config.Endpoints))
+	for i, endpoint := range config.Endpoints {
+		if endpoint.URL == "" {
+			return nil, fmt.Errorf("webhook endpoint %d (%s): url is required", i, endpoint.Name)
+		}
+		if endpoint.SecretEnv != "" {
+			endpoint.Secret = os.Getenv(endpoint.SecretEnv)
+			// never fall back to unsigned requests when a secret is expected
+			if endpoint.Secret == "" {
+				return nil, fmt.Errorf("webhook endpoint %d (%s): secret environment variable %s is not set", i, endpoint.Name, endpoint.SecretEnv)
+			}
+		}
+		if endpoint.Secret == "" {
+			return nil, fmt.Errorf("webhook endpoint %d (%s): secret or secretEnv is required to sign the events", i, endpoint.Name)
+		}
+		if endpoint.Timeout <= 0 {
+			endpoint.Timeout = 5 * time.Second
+		}
+		if endpoint.InitialBackoff <= 0 {
+			endpoint.InitialBackoff = 500 * time.Millisecond
+		}
+		if endpoint.MaxBackoff <= 0 {
+			endpoint.MaxBackoff = 30 * time.Second
+		}
+		endpoints[i] = endpoint
+	}
+
+	forwarder := &Forwarder{
+		endpoints: endpoints,
+		client:    &http.Client{},
+		queue:     make(chan delivery, config.QueueSize),
+		log:       slog.Default().With("component", "webhook"),
+		stop:      make(chan struct{}),
+	}
+	for i := 0; i < config.Workers; i++ {
+		forwarder.wg.Add(1)
+		go forwarder.work()
+	}
+
+	return forwarder, nil
+}
+
+// Publish queues the request as an event for every endpoint accepting it. It never blocks.
+func (f *Forwarder) Publish(eventType string, namespace string, request proto.Message) {
+	payload, err := protojson.Marshal(request)
+	if err != nil {
+		f.log.Error("failed to marshal webhook payload", "type", eventType, "error", err)
+
+		return
+	}
+
+	event := Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		Namespace: namespace,
+		Timestamp: time.Now().UTC(),
+		Payload:   payload,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		f.log.Error("failed to marshal webhook event", "type", eventType, "error", err)
+
+		return
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return
+	}
+
+	for i := range f.endpoints {
+		endpoint := &f.endpoints[i]
+		if !endpoint.accepts(eventType, namespace) {
+			continue
+		}
+
+		select {
+		case f.queue <- delivery{endpoint: endpoint, eventID: event.ID, body: body}:
+		default:
+			f.log.Warn("webhook queue is full, event dropped", "endpoint", endpoint.Name, "type", eventType, "id", event.ID)
+		}
+	}
+}
+
+// Close stops accepting events and waits for the queued deliveries until the context is done. The deliveries waiting
+// to be retried are then abandoned.
+func (f *Forwarder) Close(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.queue)
+	}
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		f.stopOnce.Do(func() { close(f.stop) })
+
+		return ctx.Err()
+	}
+}
+
+func (f *Forwarder) work() {
+	defer f.wg.Done()
+	for d := range f.queue {
+		f.deliver(d)
+	}
+}
+
+// deliver posts the event, retrying with exponential backoff on transport errors, 429 and 5xx responses.
+func (f *Forwarder) deliver(d delivery) {
+	backoff := d.endpoint.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := f.post(d)
+		if err == nil {
+			return
+		}
+		if !retryable || attempt >= d.endpoint.MaxRetries {
+			f.log.Error("webhook delivery failed", "endpoint", d.endpoint.Name, "id", d.eventID, "attempts", attempt+1, "error", err)
+
+			return
+		}
+
+		f.log.Warn("webhook delivery failed, retrying", "endpoint", d.endpoint.Name, "id", d.eventID, "backoff", backoff, "error", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-f.stop:
+			timer.Stop()
+			f.log.Error("webhook delivery abandoned on close", "endpoint", d.endpoint.Name, "id", d.eventID, "attempts", attempt+1)
+
+			return
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, d.endpoint.MaxBackoff)
+	}
+}
+
+func (f *Forwarder) post(d delivery) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.endpoint.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventIDHeader, d.eventID)
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, "sha256="+Sign(d.endpoint.Secret, timestamp, d.body))
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	_ = response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	retryable := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+
+	return retryable, fmt.Errorf("unexpected status %s", response.Status)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>", as sent in the signature header.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *Endpoint) accepts(eventType string, namespace string) bool {
+	if len(e.EventTypes) > 0 && !slices.Contains(e.EventTypes, eventType) {
+		return false
+	}
+
+	return len(e.Namespaces) == 0 || slices.Contains(e.Namespaces, namespace)
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
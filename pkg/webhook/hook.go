@@ -0,0 +1,54 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package webhook
+
+import (
+	"context"
+
+	sessionmanager "accelbyte.net/session-manager-grpc-plugin-server-go/pkg/pb"
+)
+
+// Name identifies the Forwarder as a hook. It publishes every callback it is called for, so register it last in a
+// hook chain for the sessions rejected by the other hooks not to be published, and for created sessions to be
+// published as returned to AGS.
+func (f *Forwarder) Name() string {
+	return "webhook"
+}
+
+func (f *Forwarder) OnSessionCreated(ctx context.Context, session *sessionmanager.GameSession) (*sessionmanager.GameSession, error) {
+	f.Publish(EventSessionCreated, session.GetSession().GetNamespace(), &sessionmanager.SessionCreatedRequest{Session: session})
+
+	return session, nil
+}
+
+func (f *Forwarder) OnSessionUpdated(ctx context.Context, request *sessionmanager.SessionUpdatedRequest) error {
+	f.Publish(EventSessionUpdated, request.GetSessionNew().GetSession().GetNamespace(), request)
+
+	return nil
+}
+
+func (f *Forwarder) OnSessionDeleted(ctx context.Context, session *sessionmanager.GameSession) error {
+	f.Publish(EventSessionDeleted, session.GetSession().GetNamespace(), &sessionmanager.SessionDeletedRequest{Session: session})
+
+	return nil
+}
+
+func (f *Forwarder) OnPartyCreated(ctx context.Context, session *sessionmanager.PartySession) (*sessionmanager.PartySession, error) {
+	f.Publish(EventPartyCreated, session.GetSession().GetNamespace(), &sessionmanager.PartyCreatedRequest{Session: session})
+
+	return session, nil
+}
+
+func (f *Forwarder) OnPartyUpdated(ctx context.Context, request *sessionmanager.PartyUpdatedRequest) error {
+	f.Publish(EventPartyUpdated, request.GetSessionNew().GetSession().GetNamespace(), request)
+
+	return nil
+}
+
+func (f *Forwarder) OnPartyDeleted(ctx context.Context, session *sessionmanager.PartySession) error {
+	f.Publish(EventPartyDeleted, session.GetSession().GetNamespace(), &sessionmanager.PartyDeletedRequest{Session: session})
+
+	return nil
+}
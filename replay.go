@@ -0,0 +1,109 @@
+// Copyright (c) 2024 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"accelbyte.net/session-manager-grpc-plugin-server-go/pkg/replay"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// runReplay implements the replay subcommand and returns the process exit code.
+func runReplay(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	target := flags.String("target", fmt.Sprintf("localhost:%d", grpcPort), "address of the plugin gRPC server")
+	journalDir := flags.String("journal", "", "directory of the recorded event journal")
+	paced := flags.Bool("paced", false, "wait between calls as long as between the recorded calls")
+	speed := flags.Float64("speed", 1, "pacing speed factor, 2 replays twice as fast")
+	token := flags.String("token", "", "access token sent as bearer authorization")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each call")
+	caFile := flags.String("ca", "", "CA certificate of the server, the connection uses TLS when set")
+	certFile := flags.String("cert", "", "client certificate, for servers requiring one")
+	keyFile := flags.String("key", "", "private key of the client certificate")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s replay -journal <dir> [options]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if *journalDir == "" {
+		flags.Usage()
+
+		return 2
+	}
+
+	transportCredentials := insecure.NewCredentials()
+	if *caFile != "" {
+		tlsConfig, err := clientTLSConfig(*caFile, *certFile, *keyFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to configure TLS:", err)
+
+			return 1
+		}
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(*target, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect:", err)
+
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := replay.Run(ctx, conn, *journalDir, replay.Options{
+		Paced:   *paced,
+		Speed:   *speed,
+		Token:   *token,
+		Timeout: *timeout,
+	})
+	replay.WriteReport(os.Stdout, report)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "replay failed:", err)
+
+		return 1
+	}
+	if len(report.Mismatches) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func clientTLSConfig(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+	content, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("no certificate found in CA file %s", caFile)
+	}
+
+	tlsConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	if certFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}